@@ -1,23 +1,32 @@
 package backup
 
 import (
-	"flag"
+	"context"
 	"fmt"
 
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
 
-var (
-	outputFileFlag = flag.String("output", "file.bak", "Output file location")
-)
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	if len(args) < 1 {
+		return &errors.CommandInvalidError{
+			Msg:      "no operation specified",
+			Commands: commands.CommandList,
+		}
+	}
 
-func Handle(rc *client.Client, args []string) error {
-	flag.Parse()
+	operation := args[0]
 
-	err := rc.Backup(*outputFileFlag)
-	if err != nil {
-		return fmt.Errorf("error saving backup: %v", err)
+	for _, cmd := range commands.CommandList {
+		if cmd.Name() == operation {
+			return cmd.Handle(ctx, rc, args[1:])
+		}
 	}
 
-	return nil
+	return &errors.CommandInvalidError{
+		Msg:      fmt.Sprintf("invalid operation specified: %s", operation),
+		Commands: commands.CommandList,
+	}
 }
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Backup struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Backup{})
+}
+
+func (c *Backup) Name() string {
+	return "backup"
+}
+
+func (c *Backup) Description() string {
+	return "Download a configuration backup"
+}
+
+func (c *Backup) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return backup.Handle(ctx, rc, args)
+}
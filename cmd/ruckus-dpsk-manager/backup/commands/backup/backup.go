@@ -0,0 +1,225 @@
+package backup
+
+import (
+	"context"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/prune"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	backupCmd := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := backupCmd.String("o", "", "Output file, - writes to stdout, s3://bucket/prefix/ uploads like -dir (default: backup-YYYYMMDD-HHMMSS.bak)")
+	manifestPath := backupCmd.String("manifest", "", "Manifest file (default: <output>"+backup.ManifestSuffix+", none when writing to stdout)")
+	noManifest := backupCmd.Bool("no-manifest", false, "Do not write a manifest")
+	signKey := backupCmd.String("sign-key", "", "Sign the manifest with this ed25519 private key (PEM)")
+	dir := backupCmd.String("dir", "", "Save the backup into this directory or s3://bucket/prefix/, named after -name")
+	name := backupCmd.String("name", backup.DefaultNameTemplate, "File name template used with -dir, fields: .Host .Time, functions: date, utc")
+	match := backupCmd.String("match", "*.bak", "With -dir and a -keep-* policy, only prune backups whose file name matches this pattern")
+	policy := prune.AddRetentionFlags(backupCmd)
+	deadline := backupCmd.Duration("deadline", 0, "Time limit for the whole operation, e.g. 5m (0 disables)")
+	backupCmd.Parse(args)
+
+	location := *dir
+	if strings.HasPrefix(*output, "s3://") {
+		location = *output
+		*output = ""
+	}
+
+	if location != "" && *output != "" {
+		return &errors.CommandError{
+			Msg:     "-o and -dir cannot be used together",
+			FlagSet: backupCmd,
+		}
+	}
+
+	if location != "" && *manifestPath != "" {
+		return &errors.CommandError{
+			Msg:     "-manifest cannot be used with -dir, the manifest is saved next to the backup",
+			FlagSet: backupCmd,
+		}
+	}
+
+	if !policy.Empty() {
+		if location == "" {
+			return &errors.CommandError{
+				Msg:     "-keep-* flags require -dir",
+				FlagSet: backupCmd,
+			}
+		}
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	var dest backup.Destination
+	var nameTemplate *backup.NameTemplate
+	if location != "" {
+		d, err := backup.ParseDestination(location)
+		if err != nil {
+			return err
+		}
+		dest = d
+
+		tmpl, err := backup.ParseNameTemplate(*name)
+		if err != nil {
+			return err
+		}
+		nameTemplate = tmpl
+	}
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	var key ed25519.PrivateKey
+	if *signKey != "" {
+		if *noManifest {
+			return fmt.Errorf("sign-key requires a manifest")
+		}
+
+		priv, err := backup.ReadPrivateKey(*signKey)
+		if err != nil {
+			return err
+		}
+		key = priv
+	}
+
+	if dest != nil {
+		fileName, err := nameTemplate.Name(backup.NameData{Host: backup.HostName(rc.Server()), Time: time.Now()})
+		if err != nil {
+			return err
+		}
+
+		buf := &capture{}
+		if _, err := rc.BackupTo(ctx, buf); err != nil {
+			return fmt.Errorf("error saving backup: %v", err)
+		}
+
+		var manifest *backup.Manifest
+		if !*noManifest {
+			manifest, err = newManifest(ctx, rc, buf.data, key)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := backup.Save(ctx, dest, fileName, buf.data, manifest); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Backup saved to %s\n", dest.Location(fileName))
+		if manifest != nil {
+			fmt.Fprintf(os.Stderr, "Manifest saved to %s (sha256 %s)\n", dest.Location(backup.ManifestPath(fileName)), manifest.SHA256)
+		}
+
+		// The new backup is the newest one, so the policy never removes it
+		if !policy.Empty() {
+			return prune.Prune(ctx, dest, *match, *policy, false, os.Stderr)
+		}
+
+		return nil
+	}
+
+	// No manifest is written for stdout unless -manifest is given
+	writeManifest := !*noManifest && (*output != "-" || *manifestPath != "")
+
+	var data []byte
+	if *output == "-" {
+		// Stream to stdout, keeping a copy only when the manifest needs it
+		var w io.Writer = os.Stdout
+		buf := &capture{}
+		if writeManifest {
+			w = io.MultiWriter(os.Stdout, buf)
+		}
+		if _, err := rc.BackupTo(ctx, w); err != nil {
+			return fmt.Errorf("error saving backup: %v", err)
+		}
+		data = buf.data
+	} else {
+		outputFile := *output
+		if outputFile == "" {
+			outputFile = "backup-" + time.Now().Format("20060102-150405") + ".bak"
+		}
+
+		if err := rc.Backup(ctx, outputFile); err != nil {
+			return fmt.Errorf("error saving backup: %v", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Backup saved to %s\n", outputFile)
+
+		if !writeManifest {
+			return nil
+		}
+
+		if *manifestPath == "" {
+			*manifestPath = backup.ManifestPath(outputFile)
+		}
+
+		saved, err := os.ReadFile(outputFile)
+		if err != nil {
+			return fmt.Errorf("error reading saved backup: %v", err)
+		}
+		data = saved
+	}
+
+	if !writeManifest {
+		return nil
+	}
+
+	manifest, err := newManifest(ctx, rc, data, key)
+	if err != nil {
+		return err
+	}
+
+	if err := manifest.WriteFile(*manifestPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Manifest saved to %s (sha256 %s)\n", *manifestPath, manifest.SHA256)
+
+	return nil
+}
+
+func newManifest(ctx context.Context, rc *client.Client, data []byte, key ed25519.PrivateKey) (*backup.Manifest, error) {
+	format, err := client.ValidateBackup(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// The firmware version is informative, a failure to read it does not fail the backup
+	firmware := ""
+	if info, err := rc.SystemInfo(ctx); err == nil {
+		firmware = info.Version
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: unable to read firmware version: %v\n", err)
+	}
+
+	manifest := backup.NewManifest(data, string(format), rc.Server(), firmware)
+	if key != nil {
+		if err := manifest.Sign(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// capture keeps a copy of everything written to it
+type capture struct {
+	data []byte
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.data = append(c.data, p...)
+	return len(p), nil
+}
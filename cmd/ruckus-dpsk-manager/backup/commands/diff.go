@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/diff"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Diff struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Diff{})
+}
+
+func (c *Diff) Name() string {
+	return "diff"
+}
+
+func (c *Diff) Description() string {
+	return "Compare the DPSK, WLAN and role lists of two backups"
+}
+
+func (c *Diff) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return diff.Handle(ctx, rc, args)
+}
@@ -0,0 +1,67 @@
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+// Exit codes follow diff(1): 0 without differences, 1 with differences and 2 on errors
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOutput := diffCmd.Bool("json", false, "Print the differences as JSON")
+	showSecrets := diffCmd.Bool("show-secrets", false, "Show changed passphrases instead of masking them")
+	diffCmd.Parse(args)
+
+	if diffCmd.NArg() != 2 {
+		return &errors.ExitCodeError{Code: 2, Err: &errors.CommandError{
+			Msg:     "usage: config diff [flags] <old file> <new file>",
+			FlagSet: diffCmd,
+		}}
+	}
+
+	diff, err := compare(diffCmd.Arg(0), diffCmd.Arg(1))
+	if err != nil {
+		return &errors.ExitCodeError{Code: 2, Err: err}
+	}
+
+	if !*showSecrets {
+		diff.MaskSecrets()
+	}
+
+	if *jsonOutput {
+		output, err := json.Marshal(diff)
+		if err != nil {
+			return &errors.ExitCodeError{Code: 2, Err: err}
+		}
+		fmt.Println(string(output))
+	} else {
+		diff.WriteText(os.Stdout)
+	}
+
+	if !diff.Empty() {
+		return &errors.ExitCodeError{Code: 1}
+	}
+
+	return nil
+}
+
+func compare(oldFile, newFile string) (*backup.Diff, error) {
+	oldArchive, err := backup.OpenArchive(oldFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %v", err)
+	}
+
+	newArchive, err := backup.OpenArchive(newFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %v", err)
+	}
+
+	return backup.Compare(oldArchive, newArchive)
+}
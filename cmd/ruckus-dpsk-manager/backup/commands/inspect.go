@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/inspect"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Inspect struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Inspect{})
+}
+
+func (c *Inspect) Name() string {
+	return "inspect"
+}
+
+func (c *Inspect) Description() string {
+	return "Show the DPSK, WLAN or role lists stored in a backup"
+}
+
+func (c *Inspect) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return inspect.Handle(ctx, rc, args)
+}
@@ -0,0 +1,135 @@
+package inspect
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	inspectCmd := flag.NewFlagSet("inspect", flag.ExitOnError)
+	inspectCmd.Parse(args)
+
+	if inspectCmd.NArg() < 2 {
+		return &errors.CommandError{
+			Msg:     "usage: config inspect <file> dpsk|wlan|role [filter flags]",
+			FlagSet: inspectCmd,
+		}
+	}
+
+	archive, err := backup.OpenArchive(inspectCmd.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error reading backup: %v", err)
+	}
+
+	var result interface{}
+	var output *render.Options
+	switch inspectCmd.Arg(1) {
+	case "dpsk":
+		result, output, err = inspectDpsk(archive, inspectCmd.Args()[2:])
+	case "wlan", "role":
+		listCmd := flag.NewFlagSet(inspectCmd.Arg(1), flag.ExitOnError)
+		output = render.AddFlags(listCmd)
+		listCmd.Parse(inspectCmd.Args()[2:])
+		if err := validateOutput(output, listCmd); err != nil {
+			return err
+		}
+
+		if inspectCmd.Arg(1) == "wlan" {
+			result, err = archive.Wlans()
+		} else {
+			result, err = archive.Roles()
+		}
+	default:
+		return &errors.CommandError{
+			Msg:     fmt.Sprintf("unknown list %q, usage: config inspect <file> dpsk|wlan|role [filter flags]", inspectCmd.Arg(1)),
+			FlagSet: inspectCmd,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return output.Render(os.Stdout, result)
+}
+
+func validateOutput(output *render.Options, flagSet *flag.FlagSet) error {
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: flagSet,
+		}
+	}
+	return nil
+}
+
+type dpskWithSSID struct {
+	dpsk.Extended
+	SSID string `json:"ssid"`
+}
+
+// inspectDpsk filters the DPSK entries of the backup with the same flags as dpsk list,
+// resolving WLAN and role names against the lists stored in the backup
+func inspectDpsk(archive *backup.Archive, args []string) (interface{}, *render.Options, error) {
+	filtersFlagSet := flag.NewFlagSet("filter flags", flag.ExitOnError)
+	withSSID := filtersFlagSet.Bool("with-ssid", false, "Include the SSID of the WLAN service in the output")
+	output := render.AddFlags(filtersFlagSet, dpsk.TableFields...)
+
+	filterFlags, err := filters.NewDpskFilterFlags(filtersFlagSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	filterFlags.AddName("wlan", "wlansvc-id", "filter by WLAN SSID or name", func(_ context.Context, name string) (string, error) { return archive.ResolveWlan(name) })
+	filterFlags.AddName("role", "role-id", "filter by role name", func(_ context.Context, name string) (string, error) { return archive.ResolveRole(name) })
+
+	filtersFlagSet.Parse(args)
+
+	if err := validateOutput(output, filtersFlagSet); err != nil {
+		return nil, nil, err
+	}
+
+	filterMap, err := filterFlags.Filters(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dpskList, err := archive.Dpsk()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading DPSK list: %v", err)
+	}
+
+	now := time.Now()
+	matches, err := dpskList.FilterAt(filterMap, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error filtering DPSK list: %v", err)
+	}
+
+	if !*withSSID {
+		return matches.Extend(now), output, nil
+	}
+
+	wlans, err := archive.Wlans()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading WLAN list: %v", err)
+	}
+
+	result := make(map[int]dpskWithSSID, len(matches))
+	for id, entry := range matches {
+		enriched := dpskWithSSID{Extended: entry.Extend(now)}
+		if wlan, ok := wlans[entry.WlansvcID]; ok {
+			enriched.SSID = wlan.SSID
+		}
+		result[id] = enriched
+	}
+
+	return result, output, nil
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/keygen"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Keygen struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Keygen{})
+}
+
+func (c *Keygen) Name() string {
+	return "keygen"
+}
+
+func (c *Keygen) Description() string {
+	return "Generate a key pair to sign backup manifests"
+}
+
+func (c *Keygen) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return keygen.Handle(ctx, rc, args)
+}
@@ -0,0 +1,27 @@
+package keygen
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	keygenCmd := flag.NewFlagSet("keygen", flag.ExitOnError)
+	name := keygenCmd.String("o", "backup-signing", "Key file prefix, writes <prefix>.key and <prefix>.pub")
+	keygenCmd.Parse(args)
+
+	privatePath := *name + ".key"
+	publicPath := *name + ".pub"
+
+	if err := backup.GenerateKey(privatePath, publicPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Private key saved to %s, public key saved to %s\n", privatePath, publicPath)
+
+	return nil
+}
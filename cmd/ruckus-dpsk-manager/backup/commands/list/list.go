@@ -0,0 +1,79 @@
+package list
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type backupFile struct {
+	File     string    `json:"file"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Format   string    `json:"format,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := listCmd.String("dir", ".", "Directory containing the backups")
+	output := render.AddFlags(listCmd)
+	listCmd.Parse(args)
+
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: listCmd,
+		}
+	}
+
+	dirEntries, err := os.ReadDir(*dir)
+	if err != nil {
+		return fmt.Errorf("error reading backup directory: %v", err)
+	}
+
+	backups := []backupFile{}
+	for _, entry := range dirEntries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".bak") {
+			continue
+		}
+
+		path := filepath.Join(*dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		backup := backupFile{File: path, Size: info.Size(), Modified: info.ModTime()}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+
+		format, err := client.ValidateBackup(data)
+		if err != nil {
+			backup.Error = err.Error()
+		} else {
+			backup.Format = string(format)
+		}
+
+		backups = append(backups, backup)
+	}
+
+	// Newest first
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Modified.After(backups[j].Modified)
+	})
+
+	return output.Render(os.Stdout, backups)
+}
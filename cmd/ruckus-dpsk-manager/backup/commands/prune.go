@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/prune"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Prune struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Prune{})
+}
+
+func (c *Prune) Name() string {
+	return "prune"
+}
+
+func (c *Prune) Description() string {
+	return "Remove old backups from a directory following a retention policy"
+}
+
+func (c *Prune) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return prune.Handle(ctx, rc, args)
+}
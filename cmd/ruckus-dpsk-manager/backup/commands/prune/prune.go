@@ -0,0 +1,72 @@
+package prune
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+// AddRetentionFlags registers the -keep-* flags shared by the backup and prune commands
+func AddRetentionFlags(flagSet *flag.FlagSet) *backup.RetentionPolicy {
+	policy := &backup.RetentionPolicy{}
+	flagSet.IntVar(&policy.Last, "keep-last", 0, "Keep the newest N backups")
+	flagSet.IntVar(&policy.Daily, "keep-daily", 0, "Keep the newest backup of each of the last N days with backups")
+	flagSet.IntVar(&policy.Weekly, "keep-weekly", 0, "Keep the newest backup of each of the last N weeks with backups")
+	flagSet.IntVar(&policy.Monthly, "keep-monthly", 0, "Keep the newest backup of each of the last N months with backups")
+	return policy
+}
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	pruneCmd := flag.NewFlagSet("prune", flag.ExitOnError)
+	dir := pruneCmd.String("dir", ".", "Directory or s3://bucket/prefix/ containing the backups")
+	match := pruneCmd.String("match", "*.bak", "Only consider backups whose file name matches this pattern")
+	dryRun := pruneCmd.Bool("dry-run", false, "Show what would be removed without removing anything")
+	policy := AddRetentionFlags(pruneCmd)
+	pruneCmd.Parse(args)
+
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	dest, err := backup.ParseDestination(*dir)
+	if err != nil {
+		return err
+	}
+
+	return Prune(ctx, dest, *match, *policy, *dryRun, os.Stdout)
+}
+
+// Prune applies policy to the backups in dest matching pattern, reporting every decision to out
+func Prune(ctx context.Context, dest backup.Destination, pattern string, policy backup.RetentionPolicy, dryRun bool, out io.Writer) error {
+	items, err := backup.Scan(ctx, dest, pattern)
+	if err != nil {
+		return err
+	}
+
+	keep, remove := policy.Apply(items)
+
+	for _, item := range keep {
+		fmt.Fprintf(out, "keep   %s (%s)\n", dest.Location(item.Name), item.Time.Format("2006-01-02 15:04:05"))
+	}
+
+	removed := 0
+	for _, item := range remove {
+		if dryRun {
+			fmt.Fprintf(out, "would remove %s (%s)\n", dest.Location(item.Name), item.Time.Format("2006-01-02 15:04:05"))
+			continue
+		}
+
+		if err := backup.Remove(ctx, dest, item.Name); err != nil {
+			return fmt.Errorf("removed %d of %d backups: %v", removed, len(remove), err)
+		}
+		removed++
+		fmt.Fprintf(out, "remove %s (%s)\n", dest.Location(item.Name), item.Time.Format("2006-01-02 15:04:05"))
+	}
+
+	return nil
+}
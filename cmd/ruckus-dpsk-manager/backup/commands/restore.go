@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/restore"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Restore struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Restore{})
+}
+
+func (c *Restore) Name() string {
+	return "restore"
+}
+
+func (c *Restore) Description() string {
+	return "Restore a configuration backup"
+}
+
+func (c *Restore) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return restore.Handle(ctx, rc, args)
+}
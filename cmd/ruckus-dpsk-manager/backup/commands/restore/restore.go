@@ -0,0 +1,66 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	restoreCmd := flag.NewFlagSet("restore", flag.ExitOnError)
+	yes := restoreCmd.Bool("yes", false, "Restore without asking for confirmation")
+	deadline := restoreCmd.Duration("deadline", 0, "Time limit for the whole operation, e.g. 5m (0 disables)")
+	restoreCmd.Parse(args)
+
+	if restoreCmd.NArg() != 1 {
+		return &errors.CommandError{
+			Msg:     "usage: config restore [flags] <file>",
+			FlagSet: restoreCmd,
+		}
+	}
+	file := restoreCmd.Arg(0)
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading backup: %v", err)
+	}
+
+	format, err := client.ValidateBackup(data)
+	if err != nil {
+		return fmt.Errorf("refusing to restore %s: %v", file, err)
+	}
+
+	fmt.Printf("Backup %s is valid (%s, %d bytes)\n", file, format, len(data))
+	fmt.Println("Restoring replaces the whole controller configuration and restarts the controller.")
+
+	if !*yes {
+		confirmed, err := helpers.Confirm(os.Stdin, os.Stdout, "Restore this backup?")
+		if err != nil {
+			return fmt.Errorf("error reading confirmation: %v", err)
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted, nothing restored")
+		}
+	}
+
+	if err := rc.Restore(ctx, bytes.NewReader(data)); err != nil {
+		if stderrors.Is(err, client.ErrRestoreInProgress) {
+			return fmt.Errorf("%v: wait for the controller to restart and check its configuration before uploading the backup again", err)
+		}
+		return fmt.Errorf("error restoring backup: %v", err)
+	}
+
+	fmt.Println("Backup uploaded successfully, the controller is restarting")
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup/commands/verify"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Verify struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Verify{})
+}
+
+func (c *Verify) Name() string {
+	return "verify"
+}
+
+func (c *Verify) Description() string {
+	return "Verify configuration backups"
+}
+
+func (c *Verify) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return verify.Handle(ctx, rc, args)
+}
@@ -0,0 +1,100 @@
+package verify
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	cmderrors "github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/backup"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	verifyCmd := flag.NewFlagSet("verify", flag.ExitOnError)
+	requireManifest := verifyCmd.Bool("require-manifest", false, "Fail when a backup has no manifest")
+	pubKey := verifyCmd.String("pub-key", "", "Require manifests signed by this ed25519 public key (PEM)")
+	verifyCmd.Parse(args)
+
+	if verifyCmd.NArg() == 0 {
+		return &cmderrors.CommandError{
+			Msg:     "usage: config verify [flags] <file>...",
+			FlagSet: verifyCmd,
+		}
+	}
+
+	var key ed25519.PublicKey
+	if *pubKey != "" {
+		k, err := backup.ReadPublicKey(*pubKey)
+		if err != nil {
+			return err
+		}
+		key = k
+	}
+
+	invalid := 0
+	for _, file := range verifyCmd.Args() {
+		result, err := verify(file, *requireManifest || key != nil, key)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			invalid++
+			continue
+		}
+
+		fmt.Printf("%s: %s\n", file, result)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d backups are invalid", invalid, verifyCmd.NArg())
+	}
+
+	return nil
+}
+
+func verify(file string, requireManifest bool, key ed25519.PublicKey) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	format, err := client.ValidateBackup(data)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("valid (%s, %d bytes)", format, len(data))
+
+	manifest, err := backup.ReadManifest(backup.ManifestPath(file))
+	if errors.Is(err, os.ErrNotExist) {
+		if requireManifest {
+			return "", fmt.Errorf("manifest %s not found", backup.ManifestPath(file))
+		}
+		return result + ", no manifest", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := manifest.Check(data); err != nil {
+		return "", err
+	}
+	result += fmt.Sprintf(", matches manifest (sha256 %s, %s", manifest.SHA256, manifest.Controller)
+	if manifest.Firmware != "" {
+		result += ", firmware " + manifest.Firmware
+	}
+	result += ", " + manifest.Created.Format("2006-01-02T15:04:05Z07:00") + ")"
+
+	if key != nil {
+		if err := manifest.VerifySignature(key); err != nil {
+			return "", err
+		}
+		result += ", signature valid"
+	} else if manifest.Signature != "" {
+		result += ", signed (use -pub-key to check the signature)"
+	}
+
+	return result, nil
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/backup"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
@@ -21,6 +23,6 @@ func (c *Backup) Description() string {
 	return "Manage backups"
 }
 
-func (c *Backup) Handle(rc *client.Client, args []string) error {
-	return backup.Handle(rc, args)
+func (c *Backup) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return backup.Handle(ctx, rc, args)
 }
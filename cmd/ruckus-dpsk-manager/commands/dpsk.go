@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
@@ -21,6 +23,6 @@ func (c *Dpsk) Description() string {
 	return "Manage DPSK's"
 }
 
-func (c *Dpsk) Handle(rc *client.Client, args []string) error {
-	return dpsk.Handle(rc, args)
+func (c *Dpsk) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return dpsk.Handle(ctx, rc, args)
 }
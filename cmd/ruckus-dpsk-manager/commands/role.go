@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/role"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Role struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Role{})
+}
+
+func (c *Role) Name() string {
+	return "role"
+}
+
+func (c *Role) Description() string {
+	return "Manage roles"
+}
+
+func (c *Role) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return role.Handle(ctx, rc, args)
+}
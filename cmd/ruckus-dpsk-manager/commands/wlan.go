@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/wlan"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Wlan struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Wlan{})
+}
+
+func (c *Wlan) Name() string {
+	return "wlan"
+}
+
+func (c *Wlan) Description() string {
+	return "Manage WLAN's"
+}
+
+func (c *Wlan) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return wlan.Handle(ctx, rc, args)
+}
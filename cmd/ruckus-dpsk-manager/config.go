@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultConfigPath returns the config file looked up when -config is not given
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ruckus-dpsk-manager", "config.json")
+}
+
+// loadConfigFile reads a JSON object whose keys are global flag names and applies
+// its values to every flag that was not set on the command line
+func loadConfigFile(path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	// Numbers are kept as written, float64 would turn 1000000 into 1e+06
+	var values map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("error decoding config file %s: %v", path, err)
+	}
+
+	// Flags given on the command line take precedence over the config file
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for name, value := range values {
+		if flag.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("unknown option in config file %s: %s", path, name)
+		}
+
+		if explicit[name] {
+			continue
+		}
+
+		if err := flag.Set(name, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("invalid value for %s in config file %s: %v", name, path, err)
+		}
+	}
+
+	return nil
+}
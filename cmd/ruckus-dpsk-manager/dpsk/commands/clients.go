@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands/clients"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Clients struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Clients{})
+}
+
+func (c *Clients) Name() string {
+	return "clients"
+}
+
+func (c *Clients) Description() string {
+	return "Show the clients connected with DPSK's"
+}
+
+func (c *Clients) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return clients.Handle(ctx, rc, args)
+}
@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/resolve"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/station"
+)
+
+type dpskWithClient struct {
+	dpsk.Extended
+	Online  bool               `json:"online"`
+	Clients []*station.Station `json:"clients"`
+}
+
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
+	// Generate flags for filtering
+	filtersFlagSet := flag.NewFlagSet("filter flags", flag.ExitOnError)
+	onlineOnly := filtersFlagSet.Bool("online-only", false, "Show only entries with a connected client")
+	deadline := filtersFlagSet.Duration("deadline", 0, "Time limit for the whole operation, e.g. 30s (0 disables)")
+	output := render.AddFlags(filtersFlagSet, append(append([]string{}, dpsk.TableFields...), "online")...)
+
+	filterFlags, err := filters.NewDpskFilterFlags(filtersFlagSet)
+	if err != nil {
+		return err
+	}
+	filterFlags.AddName("wlan", "wlansvc-id", "filter by WLAN SSID or name", resolve.Wlan(rc))
+	filterFlags.AddName("role", "role-id", "filter by role name", resolve.Role(rc))
+
+	// Parse the filter flags here so we can validate them
+	filtersFlagSet.Parse(args)
+
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: filtersFlagSet,
+		}
+	}
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	filterMap, err := filterFlags.Filters(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Filter validation end
+	dpskList, err := rc.Dpsk().List(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting DPSK list: %v", err)
+	}
+
+	now := time.Now()
+	matches, err := dpskList.FilterAt(filterMap, now)
+	if err != nil {
+		return fmt.Errorf("error filtering DPSK list: %v", err)
+	}
+
+	stations, err := rc.Clients().List(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting client list: %v", err)
+	}
+
+	// A shared DPSK can have several devices connected at the same time
+	result := make(map[int]dpskWithClient)
+	for id, entry := range matches {
+		sessions := stations.ForDpsk(entry.ID, entry.User, entry.WlansvcID, entry.Mac.String())
+		if *onlineOnly && len(sessions) == 0 {
+			continue
+		}
+		if sessions == nil {
+			sessions = []*station.Station{}
+		}
+		result[id] = dpskWithClient{Extended: entry.Extend(now), Online: len(sessions) > 0, Clients: sessions}
+	}
+
+	return output.Render(os.Stdout, result)
+}
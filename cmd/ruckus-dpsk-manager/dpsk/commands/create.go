@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands/create"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
@@ -21,6 +23,6 @@ func (c *Create) Description() string {
 	return "Create DPSK's"
 }
 
-func (c *Create) Handle(rc *client.Client, args []string) error {
-	return create.Handle(rc.Dpsk(), args)
+func (c *Create) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return create.Handle(ctx, rc.Dpsk(), args)
 }
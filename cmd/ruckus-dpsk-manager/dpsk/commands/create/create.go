@@ -1,31 +1,67 @@
 package create
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"strconv"
+	"sort"
+	"time"
 
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
-	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
 )
 
-type filterExact struct {
-	value *string
-	dpsk.Filter
-}
-
-func (filter *filterExact) Test(s string) bool {
-	return *filter.value == s
-}
-
-func Handle(svc *client.DpskService, args []string) error {
+func Handle(ctx context.Context, svc *client.DpskService, args []string) error {
 	dpskCmd := flag.NewFlagSet("create", flag.ExitOnError)
 	wlansvcID := dpskCmd.Int("wlansvc-id", -1, "Ruckus Wlan Service ID")
-	user := dpskCmd.String("user", "", "Username")
+	wlanName := dpskCmd.String("wlan", "", "Ruckus WLAN SSID or name, alternative to -wlansvc-id")
+	user := dpskCmd.String("user", "", "Username, a numeric suffix is added when -count is above 1")
 	dpskLen := dpskCmd.Int("dpsk-len", 8, "DPSK characger length")
+	roleID := dpskCmd.String("role-id", "", "Role ID assigned to the DPSK")
+	roleName := dpskCmd.String("role", "", "Role name assigned to the DPSK, alternative to -role-id")
+	dvlanID := dpskCmd.Int("dvlan-id", 0, "Dynamic VLAN ID assigned to the DPSK (0 disables)")
+	expire := dpskCmd.String("expire", "", "Expiration date: never, now+7d, YYYY-MM-DD, YYYY-MM-DD HH:MM:SS, RFC3339 or Unix timestamp (default: never)")
+	count := dpskCmd.Int("count", 1, fmt.Sprintf("Number of DPSK's to generate, up to %d", client.MaxDpskEntries))
+	passphraseOnly := dpskCmd.Bool("passphrase-only", false, "Print only the passphrases, one per line")
+	deadline := dpskCmd.Duration("deadline", 0, "Time limit for the whole operation, e.g. 1m (0 disables)")
 	dpskCmd.Parse(args)
 
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	if *wlanName != "" {
+		if *wlansvcID >= 0 {
+			return &errors.CommandError{
+				Msg:     "wlan and wlansvc-id are mutually exclusive",
+				FlagSet: dpskCmd,
+			}
+		}
+
+		id, err := svc.Client.Wlan().ResolveID(ctx, *wlanName)
+		if err != nil {
+			return fmt.Errorf("error resolving WLAN: %v", err)
+		}
+		*wlansvcID = id
+	}
+
+	if *roleName != "" {
+		if *roleID != "" {
+			return &errors.CommandError{
+				Msg:     "role and role-id are mutually exclusive",
+				FlagSet: dpskCmd,
+			}
+		}
+
+		id, err := svc.Client.Role().ResolveID(ctx, *roleName)
+		if err != nil {
+			return fmt.Errorf("error resolving role: %v", err)
+		}
+		*roleID = id
+	}
+
 	if *wlansvcID < 0 {
 		return &errors.CommandError{
 			Msg:     fmt.Sprintf("wlan-id is invalid: %d", *wlansvcID),
@@ -40,47 +76,65 @@ func Handle(svc *client.DpskService, args []string) error {
 		}
 	}
 
-	wlanIDStr := strconv.Itoa(*wlansvcID)
+	if *count < 1 || *count > client.MaxDpskEntries {
+		return &errors.CommandError{
+			Msg:     fmt.Sprintf("count is invalid: %d", *count),
+			FlagSet: dpskCmd,
+		}
+	}
 
-	filters := make(map[string]dpsk.Filter)
-	filters["wlansvc-id"] = &filterExact{value: &wlanIDStr}
-	filters["user"] = &filterExact{value: user}
+	if *dvlanID < 0 || *dvlanID > 4094 {
+		return &errors.CommandError{
+			Msg:     fmt.Sprintf("dvlan-id is invalid: %d", *dvlanID),
+			FlagSet: dpskCmd,
+		}
+	}
 
-	entries, err := loadEntries(svc, filters)
+	// Same formats as the filters, never and an empty value leave expireTime zero
+	expireAt, err := filters.ParseTimeLiteral(*expire, time.Now())
 	if err != nil {
-		return fmt.Errorf("error filtering DPSK list: %v", err)
+		return &errors.CommandError{
+			Msg:     fmt.Sprintf("expire is invalid: %s", *expire),
+			FlagSet: dpskCmd,
+		}
 	}
-
-	if len(entries) > 0 {
-		return fmt.Errorf("DPSK already exists for username: %s and wlanID: %d", *user, *wlansvcID)
+	var expireTime time.Time
+	if !expireAt.IsNever() {
+		expireTime = expireAt.Time
 	}
 
-	err = svc.Create(*wlansvcID, *user, *dpskLen)
+	created, err := svc.Create(ctx, client.CreateOptions{
+		WlansvcID: *wlansvcID,
+		User:      *user,
+		DpskLen:   *dpskLen,
+		RoleID:    *roleID,
+		DvlanID:   *dvlanID,
+		Expire:    expireTime,
+		Count:     *count,
+	})
 	if err != nil {
 		return fmt.Errorf("error creating DPSK user: %v", err)
 	}
 
-	entries, err = loadEntries(svc, filters)
-	if err != nil {
-		return fmt.Errorf("error filtering DPSK list: %v", err)
-	}
+	if *passphraseOnly {
+		ids := make([]int, 0, len(created))
+		for id := range created {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
 
-	for _, entry := range entries {
-		fmt.Println(entry.Passphrase)
+		for _, id := range ids {
+			fmt.Println(created[id].Passphrase)
+		}
+		return nil
 	}
-	return nil
-}
 
-func loadEntries(svc *client.DpskService, filters map[string]dpsk.Filter) (dpsk.Entries, error) {
-	dpskData, err := svc.List()
+	output, err := json.Marshal(created)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	entries, err := dpskData.Filter(filters)
-	if err != nil {
-		return nil, err
-	}
+	fmt.Println(string(output))
 
-	return entries, nil
+	return nil
 }
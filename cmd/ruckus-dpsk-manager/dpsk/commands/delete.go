@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands/remove"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type Delete struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&Delete{})
+}
+
+func (c *Delete) Name() string {
+	return "delete"
+}
+
+func (c *Delete) Description() string {
+	return "Delete DPSK's"
+}
+
+func (c *Delete) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return remove.Handle(ctx, rc.Dpsk(), args)
+}
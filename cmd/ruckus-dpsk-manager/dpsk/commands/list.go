@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands/list"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
@@ -21,6 +23,6 @@ func (c *List) Description() string {
 	return "List DPSK's"
 }
 
-func (c *List) Handle(rc *client.Client, args []string) error {
-	return list.Handle(rc.Dpsk(), args)
+func (c *List) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return list.Handle(ctx, rc.Dpsk(), args)
 }
@@ -1,83 +1,99 @@
 package list
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/resolve"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
 )
 
-func Handle(svc *client.DpskService, args []string) error {
+func Handle(ctx context.Context, svc *client.DpskService, args []string) error {
 	filterArgs := args
 
 	// Generate flags for filtering
 	filtersFlagSet := flag.NewFlagSet("filter flags", flag.ExitOnError)
-	filtersFlagSet.Usage = filters.FlagSetUsageOrdered(filtersFlagSet)
+	withSSID := filtersFlagSet.Bool("with-ssid", false, "Include the SSID of the WLAN service in the output")
+	deadline := filtersFlagSet.Duration("deadline", 0, "Time limit for the whole operation, e.g. 30s (0 disables)")
+	output := render.AddFlags(filtersFlagSet, dpsk.TableFields...)
 
-	filtersExactAll, err := filters.GenerateDpskFiltersExact(filtersFlagSet)
-	if err != nil {
-		return err
-	}
-
-	filtersRegexpAll, err := filters.GenerateDpskFiltersRegexp(filtersFlagSet, filterArgs)
+	filterFlags, err := filters.NewDpskFilterFlags(filtersFlagSet)
 	if err != nil {
 		return err
 	}
+	filterFlags.AddName("wlan", "wlansvc-id", "filter by WLAN SSID or name", resolve.Wlan(svc.Client))
+	filterFlags.AddName("role", "role-id", "filter by role name", resolve.Role(svc.Client))
 
 	// Parse the filter flags here so we can validate them
 	filtersFlagSet.Parse(filterArgs)
 
-	filtersExact, err := filters.ValidateFilters(filtersExactAll)
-	if err != nil {
-		return err
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: filtersFlagSet,
+		}
 	}
 
-	filtersRegexp, err := filters.ValidateFilters(filtersRegexpAll)
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	filterMap, err := filterFlags.Filters(ctx)
 	if err != nil {
 		return err
 	}
 
-	filterMap := make(map[string]dpsk.Filter)
-	for k, filter := range filtersExact {
-		filterMap[k] = filter
-	}
-
-	for k, filter := range filtersRegexp {
-		if _, ok := filterMap[k]; ok {
-			return fmt.Errorf("duplicate property filter: %s", k)
-		}
-
-		filterMap[k] = filter
-	}
-
-	if len(filterMap) == 0 {
-		return &errors.CommandError{
-			Msg:     "no filters specified",
-			FlagSet: filtersFlagSet,
-		}
-	}
-
 	// Filter validation end
-	dpskList, err := svc.List()
+	dpskList, err := svc.List(ctx)
 	if err != nil {
 		return fmt.Errorf("error getting DPSK list: %v", err)
 	}
 
-	matches, err := dpskList.Filter(filterMap)
+	// Derived attributes are computed once so filters and output agree
+	now := time.Now()
+	matches, err := dpskList.FilterAt(filterMap, now)
 	if err != nil {
 		return fmt.Errorf("error filtering DPSK list: %v", err)
 	}
 
-	output, err := json.Marshal(matches)
+	var result interface{} = matches.Extend(now)
+	if *withSSID {
+		result, err = withWlanSSID(ctx, svc.Client, matches, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return output.Render(os.Stdout, result)
+}
+
+type dpskWithSSID struct {
+	dpsk.Extended
+	SSID string `json:"ssid"`
+}
+
+// withWlanSSID adds the SSID of each entry's WLAN service to the output
+func withWlanSSID(ctx context.Context, rc *client.Client, entries dpsk.Entries, now time.Time) (map[int]dpskWithSSID, error) {
+	wlans, err := rc.Wlan().List(ctx)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error getting WLAN list: %v", err)
 	}
 
-	fmt.Println(string(output))
+	result := make(map[int]dpskWithSSID, len(entries))
+	for id, entry := range entries {
+		enriched := dpskWithSSID{Extended: entry.Extend(now)}
+		if wlan, ok := wlans[entry.WlansvcID]; ok {
+			enriched.SSID = wlan.SSID
+		}
+		result[id] = enriched
+	}
 
-	return nil
+	return result, nil
 }
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands/modify"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
@@ -21,6 +23,6 @@ func (c *Modify) Description() string {
 	return "Modify DPSK's"
 }
 
-func (c *Modify) Handle(rc *client.Client, args []string) error {
-	return modify.Handle(rc.Dpsk(), args)
+func (c *Modify) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return modify.Handle(ctx, rc.Dpsk(), args)
 }
@@ -1,16 +1,20 @@
 package modify
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/resolve"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
-	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
 )
 
-func Handle(svc *client.DpskService, args []string) error {
+func Handle(ctx context.Context, svc *client.DpskService, args []string) error {
 	filterArgs := args
 	valueArgs := []string{}
 	pos := FindString(args, "set")
@@ -21,60 +25,29 @@ func Handle(svc *client.DpskService, args []string) error {
 
 	// Generate flags for filtering
 	filtersFlagSet := flag.NewFlagSet("filter flags", flag.ExitOnError)
-	filtersFlagSet.Usage = filters.FlagSetUsageOrdered(filtersFlagSet)
+	deadline := filtersFlagSet.Duration("deadline", 0, "Time limit for the whole operation, e.g. 5m (0 disables)")
 
-	filtersExactAll, err := filters.GenerateDpskFiltersExact(filtersFlagSet)
-	if err != nil {
-		return err
-	}
-
-	filtersRegexpAll, err := filters.GenerateDpskFiltersRegexp(filtersFlagSet, filterArgs)
+	filterFlags, err := filters.NewDpskFilterFlags(filtersFlagSet)
 	if err != nil {
 		return err
 	}
+	filterFlags.AddName("wlan", "wlansvc-id", "filter by WLAN SSID or name", resolve.Wlan(svc.Client))
+	filterFlags.AddName("role", "role-id", "filter by role name", resolve.Role(svc.Client))
 
 	// Parse the filter flags here so we can validate them
 	filtersFlagSet.Parse(filterArgs)
 
-	filtersExact, err := filters.ValidateFilters(filtersExactAll)
-	if err != nil {
-		return err
-	}
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
 
-	filtersRegexp, err := filters.ValidateFilters(filtersRegexpAll)
+	filterMap, err := filterFlags.Filters(ctx)
 	if err != nil {
 		return err
 	}
 
-	filterMap := make(map[string]dpsk.Filter)
-	for k, filter := range filtersExact {
-		filterMap[k] = filter
-	}
-
-	for k, filter := range filtersRegexp {
-		if _, ok := filterMap[k]; ok {
-			return fmt.Errorf("duplicate property filter: %s", k)
-		}
-
-		filterMap[k] = filter
-	}
-
-	if len(filterMap) == 0 {
-		return &errors.CommandError{
-			Msg:     "no filters specified",
-			FlagSet: filtersFlagSet,
-		}
-	}
-
 	// Filter validation end
 
-	fmt.Println("Filtering by:")
-	for k, v := range filtersExact {
-		fmt.Printf("  %s: %s\n", k, v)
-	}
-	for k, v := range filtersRegexp {
-		fmt.Printf("  %s: %s\n", k, v)
-	}
+	filterFlags.Print(os.Stdout)
 
 	if pos == -1 {
 		return fmt.Errorf("set directive not found")
@@ -89,6 +62,15 @@ func Handle(svc *client.DpskService, args []string) error {
 		return err
 	}
 
+	namesAsValues := map[string]filters.ExtendedFilter{}
+	for attr, name := range map[string]*filters.FilterName{
+		"wlansvc-id": filters.GenerateDpskFilterName(valuesFlagSet, "wlan", "set wlansvc-id by WLAN SSID or name", resolve.Wlan(svc.Client)),
+		"role-id":    filters.GenerateDpskFilterName(valuesFlagSet, "role", "set role-id by role name", resolve.Role(svc.Client)),
+	} {
+		name.SetContext(ctx)
+		namesAsValues[attr] = name
+	}
+
 	// Parse the value flags here so we can validate them
 	valuesFlagSet.Parse(valueArgs)
 
@@ -97,6 +79,18 @@ func Handle(svc *client.DpskService, args []string) error {
 		return err
 	}
 
+	namedValues, err := filters.ExtractValuesFromExactFilters(namesAsValues)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range namedValues {
+		if _, ok := valuesToSet[k]; ok {
+			return fmt.Errorf("duplicate property value: %s", k)
+		}
+		valuesToSet[k] = v
+	}
+
 	if len(valuesToSet) == 0 {
 		return &errors.CommandError{
 			Msg:     "no properties specified to modify",
@@ -111,7 +105,7 @@ func Handle(svc *client.DpskService, args []string) error {
 		fmt.Printf("  %s: %s\n", k, v)
 	}
 
-	dpskListOriginal, err := svc.List()
+	dpskListOriginal, err := svc.List(ctx)
 	if err != nil {
 		return fmt.Errorf("error getting original DPSK list: %v", err)
 	}
@@ -121,11 +115,22 @@ func Handle(svc *client.DpskService, args []string) error {
 		return fmt.Errorf("error filtering original DPSK list: %v", err)
 	}
 
-	// modify the matches
+	// modify the matches, stopping cleanly between records if the context is cancelled
+	modified := 0
 	for _, dpsk := range matches {
-		if err := svc.Modify(dpsk.ID, valuesToSet); err != nil {
-			return fmt.Errorf("error modifying DPSK %d: %v", dpsk.ID, err)
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := modifyRecord(ctx, svc, dpsk.ID, valuesToSet); err != nil {
+			return fmt.Errorf("error modifying DPSK %d after modifying %d of %d records: %v", dpsk.ID, modified, len(matches), err)
 		}
+		modified++
+	}
+
+	if err := ctx.Err(); err != nil {
+		fmt.Printf("Stopped after modifying %d of %d records\n", modified, len(matches))
+		return err
 	}
 
 	// iterate over dpskList to print the modified records
@@ -134,6 +139,18 @@ func Handle(svc *client.DpskService, args []string) error {
 	return nil
 }
 
+// recordTimeout bounds the modification of a record that is finished after ctx is cancelled
+const recordTimeout = 30 * time.Second
+
+// modifyRecord modifies a single record on a context detached from ctx, so Ctrl-C or -deadline
+// never leaves a record half-modified
+func modifyRecord(ctx context.Context, svc *client.DpskService, id int, values map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+
+	return svc.Modify(ctx, id, values)
+}
+
 func FindString(slice []string, target string) int {
 	for i, v := range slice {
 		if v == target {
@@ -0,0 +1,88 @@
+package remove
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/resolve"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, svc *client.DpskService, args []string) error {
+	// Generate flags for filtering
+	filtersFlagSet := flag.NewFlagSet("filter flags", flag.ExitOnError)
+	deadline := filtersFlagSet.Duration("deadline", 0, "Time limit for the whole operation, e.g. 30s (0 disables)")
+	yes := filtersFlagSet.Bool("yes", false, "Delete without asking for confirmation")
+
+	filterFlags, err := filters.NewDpskFilterFlags(filtersFlagSet)
+	if err != nil {
+		return err
+	}
+	filterFlags.AddName("wlan", "wlansvc-id", "filter by WLAN SSID or name", resolve.Wlan(svc.Client))
+	filterFlags.AddName("role", "role-id", "filter by role name", resolve.Role(svc.Client))
+
+	// Parse the filter flags here so we can validate them
+	filtersFlagSet.Parse(args)
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	filterMap, err := filterFlags.Filters(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Filter validation end
+
+	filterFlags.Print(os.Stdout)
+
+	dpskList, err := svc.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting DPSK list: %v", err)
+	}
+
+	matches, err := dpskList.Filter(filterMap)
+	if err != nil {
+		return fmt.Errorf("error filtering DPSK list: %v", err)
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No matching records, nothing to delete")
+		return nil
+	}
+
+	ids := make([]int, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	fmt.Printf("Records to delete:\n")
+	for _, id := range ids {
+		entry := matches[id]
+		fmt.Printf("  %d: user %q, wlansvc-id %d, mac %q\n", entry.ID, entry.User, entry.WlansvcID, entry.Mac)
+	}
+
+	if !*yes {
+		confirmed, err := helpers.Confirm(os.Stdin, os.Stdout, fmt.Sprintf("Delete %d records?", len(ids)))
+		if err != nil {
+			return fmt.Errorf("error reading confirmation: %v", err)
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted, no records deleted")
+		}
+	}
+
+	if err := svc.Delete(ctx, ids...); err != nil {
+		return fmt.Errorf("error deleting DPSK records: %v", err)
+	}
+
+	fmt.Printf("Deleted %d records successfully\n", len(ids))
+
+	return nil
+}
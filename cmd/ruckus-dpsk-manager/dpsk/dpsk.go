@@ -1,6 +1,7 @@
 package dpsk
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/dpsk/commands"
@@ -8,7 +9,7 @@ import (
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
 )
 
-func Handle(rc *client.Client, args []string) error {
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
 	if len(args) < 1 {
 		return &errors.CommandInvalidError{
 			Msg:      "no operation specified",
@@ -20,7 +21,7 @@ func Handle(rc *client.Client, args []string) error {
 
 	for _, cmd := range commands.CommandList {
 		if cmd.Name() == operation {
-			return cmd.Handle(rc, args[1:])
+			return cmd.Handle(ctx, rc, args[1:])
 		}
 	}
 
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/commands"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
@@ -11,10 +14,19 @@ import (
 )
 
 var (
+	configFlag     = flag.String("config", "", "Path to a JSON config file with default values for these options (default: user config directory)")
 	serverFlag     = flag.String("server", "https://unleashed.ruckuswireless.com", "Ruckus controller server location")
 	usernameFlag   = flag.String("username", "dpsk", "Username for logging in to the Ruckus controller")
-	passwordFlag   = flag.String("password", "", "Password for logging in to the Ruckus controller")
+	passwordFlag   = flag.String("password", "", "Password for logging in to the Ruckus controller, required by commands contacting it")
 	caCertPathFlag = flag.String("cacert", "", "Path to a custom CA certificate")
+	sessionDirFlag = flag.String("session-dir", "", "Directory to persist login sessions (default: user cache directory)")
+	noSessionFlag  = flag.Bool("no-session", false, "Do not reuse or persist login sessions")
+	timeoutFlag    = flag.Duration("timeout", client.DefaultTimeout, "Time limit for each request to the Ruckus controller (0 disables)")
+	retriesFlag    = flag.Int("retries", client.DefaultRetryPolicy.MaxAttempts, "Maximum attempts for idempotent requests (1 disables retries)")
+	retryDelayFlag = flag.Duration("retry-delay", client.DefaultRetryPolicy.BaseDelay, "Initial delay between retries, doubled on every attempt")
+	retryMaxFlag   = flag.Duration("retry-max-delay", client.DefaultRetryPolicy.MaxDelay, "Maximum delay between retries")
+	rateFlag       = flag.Float64("rate", 0, "Maximum requests per second sent to the Ruckus controller (0 disables)")
+	burstFlag      = flag.Int("burst", 1, "Maximum burst of requests allowed by -rate")
 	debugFlag      = flag.Bool("debug", false, "Enable debug output")
 	helpFlag       = flag.Bool("help", false, "Print usage information")
 )
@@ -22,14 +34,17 @@ var (
 func main() {
 	flag.Parse()
 
-	if *helpFlag {
-		printUsage()
-		os.Exit(0)
+	configPath, required := *configFlag, true
+	if configPath == "" {
+		configPath, required = defaultConfigPath(), false
+	}
+	if err := loadConfigFile(configPath, required); err != nil {
+		exitWithError(fmt.Sprintf("Error: %v", err))
 	}
 
-	if *passwordFlag == "" {
+	if *helpFlag {
 		printUsage()
-		exitWithError("Error: password is required")
+		os.Exit(0)
 	}
 
 	ruckusClient, err := client.New(*serverFlag, *caCertPathFlag)
@@ -38,18 +53,44 @@ func main() {
 	}
 
 	ruckusClient.Debug = *debugFlag
+	ruckusClient.SetTimeout(*timeoutFlag)
+	ruckusClient.Retry.MaxAttempts = *retriesFlag
+	ruckusClient.Retry.BaseDelay = *retryDelayFlag
+	ruckusClient.Retry.MaxDelay = *retryMaxFlag
 
-	err = ruckusClient.Login(*usernameFlag, *passwordFlag)
-	if err != nil {
-		exitWithError(fmt.Sprintf("Error login with Ruckus client: %v", err))
+	if *rateFlag > 0 {
+		ruckusClient.RateLimiter = client.NewRateLimiter(*rateFlag, *burstFlag)
 	}
 
+	if !*noSessionFlag {
+		store, err := client.NewFileSessionStore(*sessionDirFlag)
+		if err != nil {
+			exitWithError(fmt.Sprintf("Error initializing session store: %v", err))
+		}
+		ruckusClient.SessionStore = store
+	}
+
+	// Commands log in on their first request, so offline commands work without credentials
+	ruckusClient.SetCredentials(*usernameFlag, *passwordFlag)
+
+	// Cancel in-flight requests on Ctrl-C so commands can stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
 	args := flag.Args()
-	os.Exit(start(ruckusClient, args))
+	code := start(ctx, ruckusClient, args)
+	stop()
+	os.Exit(code)
 }
 
-func start(rc *client.Client, args []string) int {
-	err := Handle(rc, args)
+func start(ctx context.Context, rc *client.Client, args []string) int {
+	err := Handle(ctx, rc, args)
+
+	if exitErr, ok := err.(*errors.ExitCodeError); ok {
+		if exitErr.Err != nil {
+			fmt.Printf("Error: %v\n", exitErr.Err)
+		}
+		return exitErr.Code
+	}
 
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -59,7 +100,7 @@ func start(rc *client.Client, args []string) int {
 	return 0
 }
 
-func Handle(rc *client.Client, args []string) error {
+func Handle(ctx context.Context, rc *client.Client, args []string) error {
 	if len(args) < 1 {
 		return &errors.CommandInvalidError{
 			Msg:      "no command specified",
@@ -70,7 +111,7 @@ func Handle(rc *client.Client, args []string) error {
 
 	for _, cmd := range commands.CommandList {
 		if cmd.Name() == operation {
-			return cmd.Handle(rc, args[1:])
+			return cmd.Handle(ctx, rc, args[1:])
 		}
 	}
 
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/cmd/ruckus-dpsk-manager/role/commands/list"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+type List struct {
+	client *client.Client
+}
+
+func init() {
+	Register(&List{})
+}
+
+func (c *List) Name() string {
+	return "list"
+}
+
+func (c *List) Description() string {
+	return "List roles"
+}
+
+func (c *List) Handle(ctx context.Context, rc *client.Client, args []string) error {
+	return list.Handle(ctx, rc.Role(), args)
+}
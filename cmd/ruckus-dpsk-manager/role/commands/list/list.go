@@ -0,0 +1,37 @@
+package list
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+func Handle(ctx context.Context, svc *client.RoleService, args []string) error {
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	deadline := listCmd.Duration("deadline", 0, "Time limit for the whole operation, e.g. 30s (0 disables)")
+	output := render.AddFlags(listCmd)
+	listCmd.Parse(args)
+
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: listCmd,
+		}
+	}
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	roleList, err := svc.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting role list: %v", err)
+	}
+
+	return output.Render(os.Stdout, roleList)
+}
@@ -0,0 +1,47 @@
+package list
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/render"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/wlan"
+)
+
+func Handle(ctx context.Context, svc *client.WlanService, args []string) error {
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	dpskOnly := listCmd.Bool("dpsk-only", false, "List only WLAN's with DPSK enabled")
+	deadline := listCmd.Duration("deadline", 0, "Time limit for the whole operation, e.g. 30s (0 disables)")
+	output := render.AddFlags(listCmd)
+	listCmd.Parse(args)
+
+	if err := output.Validate(); err != nil {
+		return &errors.CommandError{
+			Msg:     err.Error(),
+			FlagSet: listCmd,
+		}
+	}
+
+	ctx, cancel := helpers.WithDeadline(ctx, *deadline)
+	defer cancel()
+
+	wlanList, err := svc.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting WLAN list: %v", err)
+	}
+
+	matches := make(wlan.Entries)
+	for id, entry := range wlanList {
+		if *dpskOnly && !entry.Dpsk {
+			continue
+		}
+		matches[id] = entry
+	}
+
+	return output.Render(os.Stdout, matches)
+}
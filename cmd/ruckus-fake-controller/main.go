@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/fake"
+)
+
+var (
+	listenFlag     = flag.String("listen", "127.0.0.1:8080", "Address to listen on")
+	usernameFlag   = flag.String("username", "dpsk", "Username accepted by the fake controller")
+	passwordFlag   = flag.String("password", "dpsk", "Password accepted by the fake controller")
+	entriesFlag    = flag.Int("entries", 10, "Number of DPSK entries to generate at startup")
+	sessionTTLFlag = flag.Duration("session-ttl", 0, "Expire sessions after this duration (0 disables)")
+	tlsCertFlag    = flag.String("tls-cert", "", "Path to a TLS certificate, enables HTTPS together with -tls-key")
+	tlsKeyFlag     = flag.String("tls-key", "", "Path to a TLS private key")
+)
+
+func main() {
+	flag.Parse()
+
+	controller := fake.New(*usernameFlag, *passwordFlag)
+	controller.SessionTTL = *sessionTTLFlag
+	controller.AddWlan(fake.Wlan{ID: 2, Name: "Guests", SSID: "Guests", Encryption: "wpa2", Dpsk: true})
+	controller.AddRole(fake.Role{ID: 1, Name: "Contractors", Description: "Limited access", AccessPolicies: []string{"Internet only"}})
+
+	// Bind every third entry to a device and keep half of those devices connected
+	for i := 1; i <= *entriesFlag; i++ {
+		attrs := map[string]string{
+			"user":       "user" + strconv.Itoa(i),
+			"wlansvc-id": strconv.Itoa(1 + i%2),
+		}
+
+		if i%3 == 0 {
+			mac := fmt.Sprintf("02:00:00:00:%02x:%02x", i/256, i%256)
+			ip := fmt.Sprintf("10.0.%d.%d", i/250, 2+i%250)
+			attrs["mac"] = mac
+			attrs["ip-addr"] = ip
+			attrs["cur-shared-num"] = "1"
+
+			// Every sixth entry is shared by a second device, reported by user only
+			if i%6 == 0 {
+				attrs["cur-shared-num"] = "2"
+				controller.AddStation(fake.Station{
+					Mac:            fmt.Sprintf("02:00:00:01:%02x:%02x", i/256, i%256),
+					IpAddr:         fmt.Sprintf("10.1.%d.%d", i/250, 2+i%250),
+					Hostname:       "phone" + strconv.Itoa(i),
+					Ap:             "24:79:2a:00:00:02",
+					ApName:         "Office",
+					SSID:           []string{"Staff", "Guests"}[i%2],
+					Rssi:           -55,
+					User:           "user" + strconv.Itoa(i),
+					WlanID:         1 + i%2,
+					ConnectedSince: time.Now().Add(-time.Duration(i) * time.Second),
+				})
+			}
+
+			if i%2 == 0 {
+				controller.AddStation(fake.Station{
+					Mac:            mac,
+					IpAddr:         ip,
+					Hostname:       "device" + strconv.Itoa(i),
+					Ap:             "24:79:2a:00:00:01",
+					ApName:         "Lobby",
+					SSID:           []string{"Staff", "Guests"}[i%2],
+					Rssi:           -40 - i%30,
+					ConnectedSince: time.Now().Add(-time.Duration(i) * time.Minute),
+				})
+			}
+		}
+
+		controller.AddDpsk(attrs)
+	}
+
+	if (*tlsCertFlag == "") != (*tlsKeyFlag == "") {
+		fmt.Println("Error: -tls-cert and -tls-key must be used together")
+		os.Exit(1)
+	}
+
+	var err error
+	if *tlsCertFlag != "" {
+		log.Printf("Fake Ruckus controller listening on https://%s", *listenFlag)
+		err = http.ListenAndServeTLS(*listenFlag, *tlsCertFlag, *tlsKeyFlag, controller)
+	} else {
+		log.Printf("Fake Ruckus controller listening on http://%s", *listenFlag)
+		err = http.ListenAndServe(*listenFlag, controller)
+	}
+
+	log.Fatal(err)
+}
@@ -33,3 +33,21 @@ func (e *CommandInvalidError) Error() string {
 
 	return fmt.Sprintf("%s\navailable commands:\n%s", e.Msg, helpOutput)
 }
+
+// ExitCodeError makes the program exit with Code. Err is printed unless it is nil, which allows
+// commands to report a result through the exit code alone.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
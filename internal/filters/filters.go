@@ -1,18 +1,41 @@
 package filters
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/errors"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/helpers"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
 )
 
+var (
+	timestampType    = reflect.TypeOf(dpsk.Timestamp{})
+	hardwareAddrType = reflect.TypeOf(dpsk.HardwareAddr{})
+	durationType     = reflect.TypeOf(dpsk.Duration{})
+)
+
+// ParseTimestamp accepts never in addition to the formats of helpers.ParseTimestamp
+func ParseTimestamp(v string) (dpsk.Timestamp, error) {
+	if strings.EqualFold(v, "never") {
+		return dpsk.Never, nil
+	}
+
+	t, err := helpers.ParseTimestamp(v)
+	if err != nil {
+		return dpsk.Never, err
+	}
+	return dpsk.NewTimestamp(t), nil
+}
+
 type ExtendedFilter interface {
 	dpsk.Filter
 	Validate() (bool, error)
@@ -99,41 +122,8 @@ func GenerateDpskFiltersExact(flagSet *flag.FlagSet) (map[string]ExtendedFilter,
 
 		tag, ok := field.Tag.Lookup("_dpsk_attr")
 		if ok {
-			var filter FilterExact
-			switch tag {
-			case "last-rekey", "next-rekey":
-				filter = NewFilterExact(
-					flagSet.String(tag, "", fmt.Sprintf("filter by %s, valid formats: Unix timestamp, RFC3339 or YYYY-MM-DD HH:MM:SS", tag)),
-					func(v string) (string, error) {
-						time, err := helpers.ParseTimestamp(v)
-						if err != nil {
-							return "", fmt.Errorf("invalid %s timestamp '%s': %s", tag, v, err.Error())
-						}
-
-						return strconv.FormatInt(time.Unix(), 10), nil
-					},
-				)
-			case "mac":
-				filter = NewFilterExact(
-					flagSet.String(tag, "", fmt.Sprintf("filter by %s, valid formats: case insensitive AA:BB:CC:DD:EE:FF or aa-bb-cc-dd-ee-ff", tag)),
-					func(v string) (string, error) {
-						mac, ok := isValidMAC(v)
-						if !ok {
-							return "", fmt.Errorf("invalid %s address: %s", tag, v)
-						}
-						return mac.String(), nil
-					},
-				)
-			default:
-				filter = NewFilterExact(
-					flagSet.String(tag, "", fmt.Sprintf("filter by %s", tag)),
-					func(v string) (string, error) {
-						// currently no additional validation
-						return v, nil
-					},
-				)
-			}
-
+			formats, validator := exactValidator(tag, field.Type)
+			filter := NewFilterExact(flagSet.String(tag, "", fmt.Sprintf("filter by %s%s", tag, formats)), validator)
 			flagList[tag] = &filter
 		}
 	}
@@ -141,6 +131,47 @@ func GenerateDpskFiltersExact(flagSet *flag.FlagSet) (map[string]ExtendedFilter,
 	return flagList, nil
 }
 
+// exactValidator returns the accepted formats, as a usage suffix, and the validator normalizing a
+// flag value to the string form of an attribute of type t
+func exactValidator(tag string, t reflect.Type) (string, func(string) (string, error)) {
+	switch t {
+	case timestampType:
+		return ", valid formats: never, Unix timestamp, RFC3339 or YYYY-MM-DD HH:MM:SS",
+			func(v string) (string, error) {
+				timestamp, err := ParseTimestamp(v)
+				if err != nil {
+					return "", fmt.Errorf("invalid %s timestamp '%s': %s", tag, v, err.Error())
+				}
+
+				return dpsk.FormatValue(timestamp), nil
+			}
+	case hardwareAddrType:
+		return ", valid formats: case insensitive AA:BB:CC:DD:EE:FF or aa-bb-cc-dd-ee-ff",
+			func(v string) (string, error) {
+				mac, ok := isValidMAC(v)
+				if !ok {
+					return "", fmt.Errorf("invalid %s address: %s", tag, v)
+				}
+				return mac.String(), nil
+			}
+	}
+
+	if t.Kind() == reflect.Int {
+		return "", func(v string) (string, error) {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return "", fmt.Errorf("invalid %s number: %s", tag, v)
+			}
+			return strconv.Itoa(n), nil
+		}
+	}
+
+	return "", func(v string) (string, error) {
+		// currently no additional validation
+		return v, nil
+	}
+}
+
 func GenerateDpskFiltersRegexp(flagSet *flag.FlagSet, args []string) (map[string]ExtendedFilter, error) {
 	flagList := make(map[string]ExtendedFilter)
 
@@ -153,13 +184,13 @@ func GenerateDpskFiltersRegexp(flagSet *flag.FlagSet, args []string) (map[string
 			flagName := "regexp-" + tag
 
 			var filter FilterRegexp
-			switch tag {
-			case "last-rekey", "next-rekey":
+			switch field.Type {
+			case timestampType:
 				filter = NewFilterRegexp(
-					flagSet.String(flagName, "", fmt.Sprintf("filter by %s, format: unix timestamp", tag)),
+					flagSet.String(flagName, "", fmt.Sprintf("filter by %s, format: unix timestamp, 0 for never", tag)),
 				)
 
-			case "mac":
+			case hardwareAddrType:
 				filter = NewFilterRegexp(
 					flagSet.String(flagName, "", fmt.Sprintf("filter by %s, format: a6:b5:c4:d2:e2:f1 (lowercase)", tag)),
 				)
@@ -178,6 +209,30 @@ func GenerateDpskFiltersRegexp(flagSet *flag.FlagSet, args []string) (map[string
 	return flagList, nil
 }
 
+// NameResolver maps a name given on the command line to the value stored in a DPSK attribute
+type NameResolver func(ctx context.Context, name string) (string, error)
+
+// FilterName matches an attribute exactly after resolving the name given on the command line.
+// Names are resolved when the filter is validated, with the context set by SetContext.
+type FilterName struct {
+	FilterExact
+	ctx context.Context
+}
+
+// SetContext sets the context used to resolve the name, e.g. one bound to -deadline
+func (filter *FilterName) SetContext(ctx context.Context) {
+	filter.ctx = ctx
+}
+
+// GenerateDpskFilterName registers a flag matching an attribute exactly after resolving the given name
+func GenerateDpskFilterName(flagSet *flag.FlagSet, flagName string, usage string, resolve NameResolver) *FilterName {
+	filter := &FilterName{ctx: context.Background()}
+	filter.FilterExact = NewFilterExact(flagSet.String(flagName, "", usage), func(name string) (string, error) {
+		return resolve(filter.ctx, name)
+	})
+	return filter
+}
+
 func ValidateFilters(filtersMap map[string]ExtendedFilter) (map[string]ExtendedFilter, error) {
 	filtersFinal := make(map[string]ExtendedFilter)
 	for k, v := range filtersMap {
@@ -216,26 +271,41 @@ func ExtractValuesFromExactFilters(filters map[string]ExtendedFilter) (map[strin
 func FlagSetUsageOrdered(flagSet *flag.FlagSet) func() {
 	return func() {
 		{
-			// Lists to segregate flags
+			// Lists to segregate flags: exact and other flags first, then the variants matching
+			// a single value, ranges and finally the pattern filters
 			var regexpFlags []*flag.Flag
+			var notRegexpFlags []*flag.Flag
+			var globFlags []*flag.Flag
+			var notGlobFlags []*flag.Flag
+			var iexactFlags []*flag.Flag
+			var notFlags []*flag.Flag
+			var rangeFlags []*flag.Flag
 			var otherFlags []*flag.Flag
 
 			flagSet.VisitAll(func(f *flag.Flag) {
-				if strings.HasPrefix(f.Name, "regexp-") {
+				if strings.HasPrefix(f.Name, "not-regexp-") {
+					notRegexpFlags = append(notRegexpFlags, f)
+				} else if strings.HasPrefix(f.Name, "not-glob-") {
+					notGlobFlags = append(notGlobFlags, f)
+				} else if strings.HasPrefix(f.Name, "regexp-") {
 					regexpFlags = append(regexpFlags, f)
+				} else if strings.HasPrefix(f.Name, "glob-") {
+					globFlags = append(globFlags, f)
+				} else if strings.HasPrefix(f.Name, "iexact-") {
+					iexactFlags = append(iexactFlags, f)
+				} else if strings.HasPrefix(f.Name, "not-") {
+					notFlags = append(notFlags, f)
+				} else if isRangeFlag(f.Name) {
+					rangeFlags = append(rangeFlags, f)
 				} else {
 					otherFlags = append(otherFlags, f)
 				}
 			})
 
-			// Print non-regexp flags
-			for _, f := range otherFlags {
-				fmt.Fprintf(flagSet.Output(), "  -%s: %s\n", f.Name, f.Usage)
-			}
-
-			// Print regexp flags
-			for _, f := range regexpFlags {
-				fmt.Fprintf(flagSet.Output(), "  -%s: %s\n", f.Name, f.Usage)
+			for _, group := range [][]*flag.Flag{otherFlags, iexactFlags, notFlags, rangeFlags, globFlags, notGlobFlags, regexpFlags, notRegexpFlags} {
+				for _, f := range group {
+					fmt.Fprintf(flagSet.Output(), "  -%s: %s\n", f.Name, f.Usage)
+				}
 			}
 		}
 	}
@@ -252,3 +322,186 @@ func isValidMAC(mac string) (net.HardwareAddr, bool) {
 	matched, _ := regexp.MatchString(`^([0-9A-Fa-f]{2}[:-]){5}([0-9A-Fa-f]{2})$`, mac)
 	return addr, matched
 }
+
+// DpskFilterFlags registers the exact, regexp and variant filter flags for every DPSK attribute on a
+// FlagSet and, once the FlagSet is parsed, combines the ones in use into a single filter map
+type DpskFilterFlags struct {
+	FlagSet *flag.FlagSet
+	exact   map[string]ExtendedFilter
+	regexp  map[string]ExtendedFilter
+	not     map[string]ExtendedFilter
+	iexact  map[string]ExtendedFilter
+	glob    map[string]ExtendedFilter
+	ranges  map[string]ExtendedFilter
+	derived map[string]ExtendedFilter
+	names   map[string]*FilterName
+	where   *FilterWhere
+	active  map[string]ExtendedFilter
+}
+
+// WhereKey is the filter map key of the -where expression, which is not bound to one attribute
+const WhereKey = "where"
+
+func NewDpskFilterFlags(flagSet *flag.FlagSet) (*DpskFilterFlags, error) {
+	flagSet.Usage = FlagSetUsageOrdered(flagSet)
+
+	exact, err := GenerateDpskFiltersExact(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	regexpFilters, err := GenerateDpskFiltersRegexp(flagSet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	notFilters, err := GenerateDpskFiltersNot(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	iexact, err := GenerateDpskFiltersIExact(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	glob, err := GenerateDpskFiltersGlob(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := GenerateDpskFiltersRange(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := GenerateDpskFiltersDerived(flagSet)
+	if err != nil {
+		return nil, err
+	}
+
+	where := NewFilterWhere(flagSet)
+
+	return &DpskFilterFlags{
+		FlagSet: flagSet,
+		exact:   exact,
+		regexp:  regexpFilters,
+		not:     notFilters,
+		iexact:  iexact,
+		glob:    glob,
+		ranges:  ranges,
+		derived: derived,
+		names:   make(map[string]*FilterName),
+		where:   &where,
+	}, nil
+}
+
+// AddName registers a flag filtering attr by a name, e.g. -wlan filtering wlansvc-id by SSID.
+// Must be called before the FlagSet is parsed.
+func (f *DpskFilterFlags) AddName(flagName string, attr string, usage string, resolve NameResolver) {
+	f.names[attr] = GenerateDpskFilterName(f.FlagSet, flagName, usage, resolve)
+}
+
+// Filters validates the parsed flags and returns the filters to apply, names given to AddName flags
+// are resolved with ctx.
+// At least one filter must be specified and only one of -<attr> and -regexp-<attr> is allowed per
+// attribute, the -not-*, -iexact-* and -glob-* filters are keyed by flag name and combine with it,
+// -where expressions can combine any number of conditions on the same attribute.
+func (f *DpskFilterFlags) Filters(ctx context.Context) (map[string]dpsk.Filter, error) {
+	filtersExact, err := ValidateFilters(f.exact)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersRegexp, err := ValidateFilters(f.regexp)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersNot, err := ValidateFilters(f.not)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersIExact, err := ValidateFilters(f.iexact)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersGlob, err := ValidateFilters(f.glob)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersRange, err := ValidateFilters(f.ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	filtersDerived, err := ValidateFilters(f.derived)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]ExtendedFilter, len(f.names))
+	for k, filter := range f.names {
+		filter.SetContext(ctx)
+		names[k] = filter
+	}
+
+	filtersName, err := ValidateFilters(names)
+	if err != nil {
+		return nil, err
+	}
+
+	f.active = make(map[string]ExtendedFilter)
+	filterMap := make(map[string]dpsk.Filter)
+	for k, filter := range filtersExact {
+		f.active[k] = filter
+		filterMap[k] = filter
+	}
+
+	for _, filters := range []map[string]ExtendedFilter{filtersRegexp, filtersNot, filtersIExact, filtersGlob, filtersRange, filtersDerived, filtersName} {
+		for k, filter := range filters {
+			if _, ok := filterMap[k]; ok {
+				return nil, fmt.Errorf("duplicate property filter: %s", k)
+			}
+
+			f.active[k] = filter
+			filterMap[k] = filter
+		}
+	}
+
+	// The expression is AND-ed with the attribute filters
+	hasWhere, err := f.where.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if hasWhere {
+		f.active[WhereKey] = f.where
+		filterMap[WhereKey] = f.where
+	}
+
+	if len(filterMap) == 0 {
+		return nil, &errors.CommandError{
+			Msg:     "no filters specified",
+			FlagSet: f.FlagSet,
+		}
+	}
+
+	return filterMap, nil
+}
+
+// Print writes the filters in use, sorted by attribute
+func (f *DpskFilterFlags) Print(w io.Writer) {
+	keys := make([]string, 0, len(f.active))
+	for k := range f.active {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(w, "Filtering by:")
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s: %s\n", k, f.active[k])
+	}
+}
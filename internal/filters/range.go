@@ -0,0 +1,270 @@
+package filters
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+// Range flag suffixes, -<attr>-before/-after for timestamps and -<attr>-min/-max for numbers
+var rangeSuffixes = []string{"-before", "-after", "-min", "-max"}
+
+// FilterRange matches timestamps or numbers within bounds. Before and after are exclusive, min and
+// max inclusive. Timestamps set to never are outside of any range.
+type FilterRange struct {
+	timestamps bool
+	attr       string
+	lower      *string
+	upper      *string
+
+	durations bool
+
+	lowerTime, upperTime         dpsk.Timestamp
+	lowerInt, upperInt           int
+	lowerDuration, upperDuration time.Duration
+	hasLower, hasUpper           bool
+}
+
+func (filter *FilterRange) Validate() (bool, error) {
+	filter.hasLower, filter.hasUpper = *filter.lower != "", *filter.upper != ""
+
+	if filter.timestamps {
+		now := time.Now()
+		if filter.hasLower {
+			t, err := ParseTimeLiteral(*filter.lower, now)
+			if err != nil || t.IsNever() {
+				return false, fmt.Errorf("invalid -%s-after timestamp %q", filter.attr, *filter.lower)
+			}
+			filter.lowerTime = t
+		}
+		if filter.hasUpper {
+			t, err := ParseTimeLiteral(*filter.upper, now)
+			if err != nil || t.IsNever() {
+				return false, fmt.Errorf("invalid -%s-before timestamp %q", filter.attr, *filter.upper)
+			}
+			filter.upperTime = t
+		}
+	} else if filter.durations {
+		var err error
+		if filter.hasLower {
+			if filter.lowerDuration, err = dpsk.ParseDuration(*filter.lower); err != nil {
+				return false, fmt.Errorf("invalid -%s-min duration %q", filter.attr, *filter.lower)
+			}
+		}
+		if filter.hasUpper {
+			if filter.upperDuration, err = dpsk.ParseDuration(*filter.upper); err != nil {
+				return false, fmt.Errorf("invalid -%s-max duration %q", filter.attr, *filter.upper)
+			}
+		}
+	} else {
+		var err error
+		if filter.hasLower {
+			if filter.lowerInt, err = strconv.Atoi(*filter.lower); err != nil {
+				return false, fmt.Errorf("invalid -%s-min number %q", filter.attr, *filter.lower)
+			}
+		}
+		if filter.hasUpper {
+			if filter.upperInt, err = strconv.Atoi(*filter.upper); err != nil {
+				return false, fmt.Errorf("invalid -%s-max number %q", filter.attr, *filter.upper)
+			}
+		}
+	}
+
+	return filter.hasLower || filter.hasUpper, nil
+}
+
+func (filter *FilterRange) TestValue(value interface{}) bool {
+	switch v := value.(type) {
+	case dpsk.Timestamp:
+		if v.IsNever() {
+			return false
+		}
+		if filter.hasLower && !v.After(filter.lowerTime.Time) {
+			return false
+		}
+		if filter.hasUpper && !v.Before(filter.upperTime.Time) {
+			return false
+		}
+		return true
+	case dpsk.Duration:
+		if !v.Valid {
+			return false
+		}
+		if filter.hasLower && v.Duration < filter.lowerDuration {
+			return false
+		}
+		if filter.hasUpper && v.Duration > filter.upperDuration {
+			return false
+		}
+		return true
+	case int:
+		if filter.hasLower && v < filter.lowerInt {
+			return false
+		}
+		if filter.hasUpper && v > filter.upperInt {
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Test handles values in their string form, Unix seconds for timestamps and seconds for durations
+func (filter *FilterRange) Test(s string) bool {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return false
+	}
+
+	if filter.durations {
+		return filter.TestValue(dpsk.NewDuration(time.Duration(n) * time.Second))
+	}
+
+	if filter.timestamps {
+		timestamp := dpsk.Never
+		if n != 0 {
+			timestamp = dpsk.NewTimestamp(time.Unix(int64(n), 0))
+		}
+		return filter.TestValue(timestamp)
+	}
+	return filter.TestValue(n)
+}
+
+func (filter *FilterRange) String() string {
+	var parts []string
+	if filter.timestamps {
+		if filter.hasLower {
+			parts = append(parts, "after "+filter.lowerTime.String())
+		}
+		if filter.hasUpper {
+			parts = append(parts, "before "+filter.upperTime.String())
+		}
+	} else if filter.durations {
+		if filter.hasLower {
+			parts = append(parts, "min "+dpsk.FormatDuration(filter.lowerDuration))
+		}
+		if filter.hasUpper {
+			parts = append(parts, "max "+dpsk.FormatDuration(filter.upperDuration))
+		}
+	} else {
+		if filter.hasLower {
+			parts = append(parts, "min "+strconv.Itoa(filter.lowerInt))
+		}
+		if filter.hasUpper {
+			parts = append(parts, "max "+strconv.Itoa(filter.upperInt))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GenerateDpskFiltersRange registers -<attr>-before/-after flags for every timestamp attribute and
+// -<attr>-min/-max flags for every numeric attribute
+func GenerateDpskFiltersRange(flagSet *flag.FlagSet) (map[string]ExtendedFilter, error) {
+	flagList := make(map[string]ExtendedFilter)
+
+	t := reflect.TypeOf(dpsk.Dpsk{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("_dpsk_attr")
+		if !ok {
+			continue
+		}
+
+		var filter FilterRange
+		switch {
+		case field.Type == timestampType:
+			filter = FilterRange{
+				timestamps: true,
+				attr:       tag,
+				lower:      flagSet.String(tag+"-after", "", fmt.Sprintf("filter by %s later than, valid formats: now+7d, now-1w, YYYY-MM-DD, Unix timestamp, RFC3339 or YYYY-MM-DD HH:MM:SS", tag)),
+				upper:      flagSet.String(tag+"-before", "", fmt.Sprintf("filter by %s earlier than, same formats as -%s-after", tag, tag)),
+			}
+		case field.Type.Kind() == reflect.Int:
+			filter = FilterRange{
+				attr:  tag,
+				lower: flagSet.String(tag+"-min", "", fmt.Sprintf("filter by %s greater than or equal to", tag)),
+				upper: flagSet.String(tag+"-max", "", fmt.Sprintf("filter by %s less than or equal to", tag)),
+			}
+		default:
+			continue
+		}
+
+		flagList[tag] = &filter
+	}
+
+	return flagList, nil
+}
+
+// GenerateDpskFiltersDerived registers the flags of the derived attributes: boolean flags such as
+// -expired or -bound=false, and -<attr>-min/-max for durations such as -expires-in-max 7d
+func GenerateDpskFiltersDerived(flagSet *flag.FlagSet) (map[string]ExtendedFilter, error) {
+	flagList := make(map[string]ExtendedFilter)
+
+	t := reflect.TypeOf(dpsk.Derived{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("_dpsk_attr")
+		if !ok {
+			continue
+		}
+
+		switch field.Type {
+		case durationType:
+			flagList[tag] = &FilterRange{
+				durations: true,
+				attr:      tag,
+				lower:     flagSet.String(tag+"-min", "", fmt.Sprintf("filter by %s of at least, e.g. 7d, 1w2d, 36h, -1d", tag)),
+				upper:     flagSet.String(tag+"-max", "", fmt.Sprintf("filter by %s of at most, e.g. 7d, 1w2d, 36h, -1d", tag)),
+			}
+		case reflect.TypeOf(false):
+			value := new(string)
+			flagSet.Var((*boolString)(value), tag, fmt.Sprintf("filter by %s (derived), -%s or -%s=false", tag, tag, tag))
+			filter := NewFilterExact(value, func(v string) (string, error) {
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					return "", fmt.Errorf("invalid %s value: %s, expected true or false", tag, v)
+				}
+				return strconv.FormatBool(b), nil
+			})
+			flagList[tag] = &filter
+		}
+	}
+
+	return flagList, nil
+}
+
+// boolString is a boolean flag keeping its value as text, so unset can be told apart from false
+type boolString string
+
+func (b *boolString) String() string {
+	if b == nil {
+		return ""
+	}
+	return string(*b)
+}
+
+func (b *boolString) Set(s string) error {
+	*b = boolString(s)
+	return nil
+}
+
+func (b *boolString) IsBoolFlag() bool {
+	return true
+}
+
+func isRangeFlag(name string) bool {
+	for _, suffix := range rangeSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package filters
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+// FilterNot matches the values of attr its inner filter rejects. It is stored under its own key,
+// so it can be combined with a positive filter on the same attribute.
+type FilterNot struct {
+	attr   string
+	filter ExtendedFilter
+}
+
+func NewFilterNot(attr string, filter ExtendedFilter) FilterNot {
+	return FilterNot{attr: attr, filter: filter}
+}
+
+func (filter *FilterNot) Attribute() string {
+	return filter.attr
+}
+
+func (filter *FilterNot) Validate() (bool, error) {
+	return filter.filter.Validate()
+}
+
+func (filter *FilterNot) Test(s string) bool {
+	return !filter.filter.Test(s)
+}
+
+func (filter *FilterNot) TestValue(value interface{}) bool {
+	return !dpsk.Match(filter.filter, value)
+}
+
+func (filter *FilterNot) String() string {
+	return "not: " + filter.filter.String()
+}
+
+// FilterIExact matches values of attr equal to the flag value ignoring case. It is stored under
+// its own key, so it can be combined with the other filters on the same attribute.
+type FilterIExact struct {
+	attr      string
+	value     *string
+	validator func(string) (string, error)
+	validated string
+}
+
+func NewFilterIExact(attr string, value *string, validator func(string) (string, error)) FilterIExact {
+	return FilterIExact{attr: attr, value: value, validator: validator}
+}
+
+func (filter *FilterIExact) Attribute() string {
+	return filter.attr
+}
+
+func (filter *FilterIExact) Validate() (bool, error) {
+	value := *filter.value
+	if value == "" {
+		filter.validated = ""
+		return false, nil
+	}
+
+	validated, err := filter.validator(value)
+	if err != nil {
+		return false, err
+	}
+
+	filter.validated = validated
+	return true, nil
+}
+
+func (filter *FilterIExact) Test(s string) bool {
+	return strings.EqualFold(filter.validated, s)
+}
+
+func (filter *FilterIExact) String() string {
+	return "iexact: " + filter.validated
+}
+
+// FilterGlob matches values of attr against a shell pattern, * ? [a-z] and \ escapes, anchored at
+// both ends. Like FilterIExact it is stored under its own key.
+type FilterGlob struct {
+	attr  string
+	value *string
+}
+
+func NewFilterGlob(attr string, value *string) FilterGlob {
+	return FilterGlob{attr: attr, value: value}
+}
+
+func (filter *FilterGlob) Attribute() string {
+	return filter.attr
+}
+
+func (filter *FilterGlob) Validate() (bool, error) {
+	pattern := *filter.value
+	if pattern == "" {
+		return false, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return false, fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+	}
+
+	return true, nil
+}
+
+func (filter *FilterGlob) Test(s string) bool {
+	// Values never contain a path, so / is matched like any other character
+	matched, _ := path.Match(strings.ReplaceAll(*filter.value, "/", "\x00"), strings.ReplaceAll(s, "/", "\x00"))
+	return matched
+}
+
+func (filter *FilterGlob) String() string {
+	return "glob: " + *filter.value
+}
+
+// GenerateDpskFiltersNot registers the negated filters of every attribute, keyed by flag name:
+// -not-<attr> excludes what -<attr> would match, with the same accepted formats, and
+// -not-glob-<attr> and -not-regexp-<attr> exclude values matching a pattern
+func GenerateDpskFiltersNot(flagSet *flag.FlagSet) (map[string]ExtendedFilter, error) {
+	flagList := make(map[string]ExtendedFilter)
+
+	t := reflect.TypeOf(dpsk.Dpsk{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("_dpsk_attr")
+		if !ok {
+			continue
+		}
+
+		formats, validator := exactValidator(tag, field.Type)
+		exact := NewFilterExact(flagSet.String("not-"+tag, "", fmt.Sprintf("exclude by %s%s", tag, formats)), validator)
+		notExact := NewFilterNot(tag, &exact)
+		flagList["not-"+tag] = &notExact
+
+		glob := NewFilterGlob(tag, flagSet.String("not-glob-"+tag, "", fmt.Sprintf("exclude by %s, shell pattern e.g. admin-*", tag)))
+		notGlob := NewFilterNot(tag, &glob)
+		flagList["not-glob-"+tag] = &notGlob
+
+		re := NewFilterRegexp(flagSet.String("not-regexp-"+tag, "", fmt.Sprintf("exclude by %s, regular expression", tag)))
+		notRegexp := NewFilterNot(tag, &re)
+		flagList["not-regexp-"+tag] = &notRegexp
+	}
+
+	return flagList, nil
+}
+
+// GenerateDpskFiltersIExact registers -iexact-<attr> for the text attributes, keyed by flag name,
+// the other ones are already normalized by -<attr>
+func GenerateDpskFiltersIExact(flagSet *flag.FlagSet) (map[string]ExtendedFilter, error) {
+	flagList := make(map[string]ExtendedFilter)
+
+	t := reflect.TypeOf(dpsk.Dpsk{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("_dpsk_attr")
+		if ok && field.Type.Kind() == reflect.String {
+			_, validator := exactValidator(tag, field.Type)
+			filter := NewFilterIExact(tag, flagSet.String("iexact-"+tag, "", fmt.Sprintf("filter by %s, case insensitive", tag)), validator)
+			flagList["iexact-"+tag] = &filter
+		}
+	}
+
+	return flagList, nil
+}
+
+// GenerateDpskFiltersGlob registers -glob-<attr> for every attribute, keyed by flag name, matching
+// the same value format as the regexp filters
+func GenerateDpskFiltersGlob(flagSet *flag.FlagSet) (map[string]ExtendedFilter, error) {
+	flagList := make(map[string]ExtendedFilter)
+
+	t := reflect.TypeOf(dpsk.Dpsk{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("_dpsk_attr")
+		if ok {
+			filter := NewFilterGlob(tag, flagSet.String("glob-"+tag, "", fmt.Sprintf("filter by %s, shell pattern e.g. guest-*", tag)))
+			flagList["glob-"+tag] = &filter
+		}
+	}
+
+	return flagList, nil
+}
@@ -0,0 +1,91 @@
+package filters
+
+import (
+	"context"
+	"flag"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+func TestDpskFilterFlagsVariants(t *testing.T) {
+	entries := dpsk.Entries{
+		1: {ID: 1, User: "guest-1"},
+		2: {ID: 2, User: "guest-admin"},
+		3: {ID: 3, User: "Guest-3"},
+		4: {ID: 4, User: "admin"},
+		5: {ID: 5, User: "guest-15"},
+	}
+
+	tests := []struct {
+		args []string
+		want []int
+	}{
+		{[]string{"-glob-user", "guest-*"}, []int{1, 2, 5}},
+		{[]string{"-glob-user", "guest-*", "-not-user", "guest-admin"}, []int{1, 5}},
+		{[]string{"-glob-user", "guest-*", "-not-glob-user", "*admin*"}, []int{1, 5}},
+		{[]string{"-regexp-user", "^guest-", "-not-regexp-user", `\d{2}$`, "-not-id", "2"}, []int{1}},
+		{[]string{"-iexact-user", "GUEST-3"}, []int{3}},
+		{[]string{"-not-glob-user", "[gG]uest-*"}, []int{4}},
+		{[]string{"-glob-user", "guest-?"}, []int{1}},
+		{[]string{"-user", "guest-1", "-iexact-user", "GUEST-3"}, []int{}},
+		{[]string{"-iexact-user", "GUEST-ADMIN", "-glob-user", "guest-*"}, []int{2}},
+		{[]string{"-regexp-user", "^[gG]uest", "-iexact-user", "guest-3", "-glob-user", "*3"}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		filterFlags, err := NewDpskFilterFlags(flagSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := flagSet.Parse(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+
+		filterMap, err := filterFlags.Filters(context.Background())
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+
+		matches, err := entries.Filter(filterMap)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+
+		var got []int
+		for id := range matches {
+			got = append(got, id)
+		}
+		sort.Ints(got)
+
+		if len(got) != len(tt.want) {
+			t.Errorf("%v matched %v, want %v", tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%v matched %v, want %v", tt.args, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDpskFilterFlagsDuplicate(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	filterFlags, err := NewDpskFilterFlags(flagSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagSet.Parse([]string{"-user", "a", "-regexp-user", "^b"})
+
+	if _, err := filterFlags.Filters(context.Background()); err == nil {
+		t.Error("two positive filters on user were accepted")
+	}
+}
@@ -0,0 +1,622 @@
+package filters
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+// WhereUsage documents the expression language accepted by -where
+const WhereUsage = `filter by a boolean expression, e.g. 'wlansvc-id == 3 && (user =~ "^guest-" || expire < now+7d) && mac != ""'. ` +
+	`Operators: && || ! (or AND OR NOT), parentheses, == != < <= > >=, =~ !~ (regexp), IS EMPTY, IS NOT EMPTY. ` +
+	`Timestamps accept never, now+7d (units s m h d w), Unix seconds, YYYY-MM-DD and RFC3339, durations 7d, 1w2d or 36h`
+
+// Expression is a parsed -where expression evaluated against DPSK entries
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// ParseWhere parses a boolean expression over DPSK attributes. Literals are converted to the type of
+// the attribute they are compared with, so type errors are reported before anything is evaluated.
+func ParseWhere(source string) (*Expression, error) {
+	tokens, err := lexWhere(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &whereParser{tokens: tokens, now: time.Now()}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) Match(entry *dpsk.Dpsk) bool {
+	return e.root.eval(entry)
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// FilterWhere is the -where flag, matching whole entries instead of a single attribute
+type FilterWhere struct {
+	value *string
+	expr  *Expression
+}
+
+func NewFilterWhere(flagSet *flag.FlagSet) FilterWhere {
+	return FilterWhere{value: flagSet.String("where", "", WhereUsage)}
+}
+
+func (filter *FilterWhere) Validate() (bool, error) {
+	if strings.TrimSpace(*filter.value) == "" {
+		return false, nil
+	}
+
+	expr, err := ParseWhere(*filter.value)
+	if err != nil {
+		return false, fmt.Errorf("invalid -where expression: %v", err)
+	}
+
+	filter.expr = expr
+	return true, nil
+}
+
+// Test is never called for entry filters, see dpsk.EntryFilter
+func (filter *FilterWhere) Test(s string) bool {
+	return false
+}
+
+func (filter *FilterWhere) TestEntry(entry *dpsk.Dpsk) bool {
+	return filter.expr.Match(entry)
+}
+
+func (filter *FilterWhere) String() string {
+	return *filter.value
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string // Unquoted value of strings
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// Longest operators first so "<=" is not read as "<"
+var whereOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "=", "!"}
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()"'=!<>&|`, r)
+}
+
+func lexWhere(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var value strings.Builder
+			closed := false
+			for i < len(runes) {
+				// Only \" and \\ are escapes, other backslashes are kept for regexps such as "\d+"
+				if runes[i] == '\\' && r == '"' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					value.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == r {
+					closed = true
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(runes[start:i]), pos: start, value: value.String()})
+
+		default:
+			matched := false
+			for _, op := range whereOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+
+			if !isWordRune(r) {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+			}
+
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// Parser, from lowest to highest precedence: OR, AND, NOT, comparisons
+
+type whereParser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *whereParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *whereParser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), tok.pos+1)
+}
+
+// accept consumes the next token if it is one of the given operators or case insensitive keywords
+func (p *whereParser) accept(words ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) {
+			p.next()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *whereParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||", "or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&", "and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *whereParser) parseNot() (exprNode, error) {
+	if p.accept("!", "not") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *whereParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\", found %s", closing)
+		}
+		return inner, nil
+
+	case tokWord:
+		return p.parseComparison(tok)
+
+	default:
+		return nil, p.errorf(tok, "expected an attribute, \"(\" or \"!\", found %s", tok)
+	}
+}
+
+func (p *whereParser) parseComparison(attrTok token) (exprNode, error) {
+	attr := attrTok.text
+	zero, err := (&dpsk.Dpsk{}).Value(attr)
+	if err != nil {
+		return nil, p.errorf(attrTok, "unknown attribute %q", attr)
+	}
+
+	// attr IS [NOT] EMPTY
+	if p.accept("is") {
+		negate := p.accept("not")
+		if !p.accept("empty") {
+			return nil, p.errorf(p.peek(), "expected EMPTY, found %s", p.peek())
+		}
+		var node exprNode = &emptyNode{attr: attr, now: p.now}
+		if negate {
+			node = &notNode{node}
+		}
+		return node, nil
+	}
+
+	// A boolean attribute on its own, e.g. expired or !bound
+	if _, ok := zero.(bool); ok && !isComparisonOp(p.peek()) {
+		return &compareNode{attr: attr, op: "==", value: true, now: p.now}, nil
+	}
+
+	opTok := p.next()
+	if !isComparisonOp(opTok) {
+		return nil, p.errorf(opTok, "expected a comparison operator after %q, found %s", attr, opTok)
+	}
+	op := opTok.text
+	if op == "=" {
+		op = "=="
+	}
+
+	litTok := p.next()
+	if litTok.kind != tokWord && litTok.kind != tokString {
+		return nil, p.errorf(litTok, "expected a value after %q, found %s", op, litTok)
+	}
+	literal := litTok.text
+	if litTok.kind == tokString {
+		literal = litTok.value
+	}
+
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile(literal)
+		if err != nil {
+			return nil, p.errorf(litTok, "invalid regexp: %v", err)
+		}
+		var node exprNode = &matchNode{attr: attr, re: re, now: p.now}
+		if op == "!~" {
+			node = &notNode{node}
+		}
+		return node, nil
+	}
+
+	value, err := p.convert(zero, literal)
+	if err != nil {
+		return nil, p.errorf(litTok, "invalid value for %s: %v", attr, err)
+	}
+
+	switch zero.(type) {
+	case dpsk.HardwareAddr, bool:
+		if op != "==" && op != "!=" {
+			return nil, p.errorf(opTok, "%s only supports == and !=", attr)
+		}
+	}
+
+	return &compareNode{attr: attr, op: op, value: value, now: p.now}, nil
+}
+
+func isComparisonOp(tok token) bool {
+	return tok.kind == tokOp && tok.text != "!" && tok.text != "&&" && tok.text != "||"
+}
+
+// convert parses literal as the type of the attribute value zero
+func (p *whereParser) convert(zero interface{}, literal string) (interface{}, error) {
+	switch zero.(type) {
+	case string:
+		return literal, nil
+	case int:
+		if literal == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(literal)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", literal)
+		}
+		return n, nil
+	case bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", literal)
+		}
+		return b, nil
+	case dpsk.HardwareAddr:
+		if literal == "" {
+			return dpsk.HardwareAddr(nil), nil
+		}
+		mac, ok := isValidMAC(literal)
+		if !ok {
+			return nil, fmt.Errorf("invalid MAC address %q", literal)
+		}
+		return dpsk.HardwareAddr(mac), nil
+	case dpsk.Timestamp:
+		return ParseTimeLiteral(literal, p.now)
+	case dpsk.Duration:
+		if literal == "" {
+			return dpsk.Duration{}, nil
+		}
+		d, err := dpsk.ParseDuration(literal)
+		if err != nil {
+			return nil, err
+		}
+		return dpsk.NewDuration(d), nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute type %T", zero)
+	}
+}
+
+var relativeTimePattern = regexp.MustCompile(`^(?i)now((?:[+-](?:\d+[smhdw])+)*)$`)
+var relativeTimeOffset = regexp.MustCompile(`([+-])((?:\d+[smhdwSMHDW])+)`)
+var relativeTimePart = regexp.MustCompile(`(\d+)([smhdwSMHDW])`)
+
+// ParseTimeLiteral accepts never, now with optional offsets such as now+7d or now-1w2d, YYYY-MM-DD
+// and the formats of ParseTimestamp
+func ParseTimeLiteral(literal string, now time.Time) (dpsk.Timestamp, error) {
+	if literal == "" {
+		return dpsk.Never, nil
+	}
+
+	if m := relativeTimePattern.FindStringSubmatch(literal); m != nil {
+		t := now
+		for _, group := range relativeTimeOffset.FindAllStringSubmatch(m[1], -1) {
+			var offset time.Duration
+			for _, part := range relativeTimePart.FindAllStringSubmatch(group[2], -1) {
+				n, _ := strconv.Atoi(part[1])
+				unit := map[string]time.Duration{
+					"s": time.Second,
+					"m": time.Minute,
+					"h": time.Hour,
+					"d": 24 * time.Hour,
+					"w": 7 * 24 * time.Hour,
+				}[strings.ToLower(part[2])]
+				offset += time.Duration(n) * unit
+			}
+			if group[1] == "-" {
+				offset = -offset
+			}
+			t = t.Add(offset)
+		}
+		return dpsk.NewTimestamp(t), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", literal, time.Local); err == nil {
+		return dpsk.NewTimestamp(t), nil
+	}
+
+	t, err := ParseTimestamp(literal)
+	if err != nil {
+		return dpsk.Never, fmt.Errorf("invalid timestamp %q", literal)
+	}
+	return t, nil
+}
+
+// Evaluation
+
+type exprNode interface {
+	eval(entry *dpsk.Dpsk) bool
+}
+
+type andNode struct{ left, right exprNode }
+
+func (n *andNode) eval(entry *dpsk.Dpsk) bool { return n.left.eval(entry) && n.right.eval(entry) }
+
+type orNode struct{ left, right exprNode }
+
+func (n *orNode) eval(entry *dpsk.Dpsk) bool { return n.left.eval(entry) || n.right.eval(entry) }
+
+type notNode struct{ inner exprNode }
+
+func (n *notNode) eval(entry *dpsk.Dpsk) bool { return !n.inner.eval(entry) }
+
+type emptyNode struct {
+	attr string
+	now  time.Time
+}
+
+func (n *emptyNode) eval(entry *dpsk.Dpsk) bool {
+	value, err := entry.ValueAt(n.attr, n.now)
+	if err != nil {
+		return false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	case bool:
+		return !v
+	case dpsk.HardwareAddr:
+		return len(v) == 0
+	case dpsk.Timestamp:
+		return v.IsNever()
+	case dpsk.Duration:
+		return !v.Valid
+	default:
+		return false
+	}
+}
+
+type matchNode struct {
+	attr string
+	re   *regexp.Regexp
+	now  time.Time
+}
+
+func (n *matchNode) eval(entry *dpsk.Dpsk) bool {
+	value, err := entry.ValueAt(n.attr, n.now)
+	if err != nil {
+		return false
+	}
+	return n.re.MatchString(dpsk.FormatValue(value))
+}
+
+type compareNode struct {
+	attr  string
+	op    string
+	value interface{}
+	now   time.Time // Derived attributes are computed at parse time, like relative time literals
+}
+
+func (n *compareNode) eval(entry *dpsk.Dpsk) bool {
+	value, err := entry.ValueAt(n.attr, n.now)
+	if err != nil {
+		return false
+	}
+
+	var cmp int
+	switch v := value.(type) {
+	case string:
+		cmp = strings.Compare(v, n.value.(string))
+	case int:
+		cmp = compareInts(int64(v), int64(n.value.(int)))
+	case bool:
+		if v == n.value.(bool) {
+			cmp = 0
+		} else {
+			cmp = 1
+		}
+	case dpsk.HardwareAddr:
+		cmp = strings.Compare(v.String(), n.value.(dpsk.HardwareAddr).String())
+	case dpsk.Timestamp:
+		other := n.value.(dpsk.Timestamp)
+		// Never is only equal to never, it is neither before nor after any time
+		if v.IsNever() || other.IsNever() {
+			equal := v.IsNever() && other.IsNever()
+			switch n.op {
+			case "==":
+				return equal
+			case "!=":
+				return !equal
+			default:
+				return false
+			}
+		}
+		cmp = compareInts(v.Unix(), other.Unix())
+	case dpsk.Duration:
+		other := n.value.(dpsk.Duration)
+		// Unset durations are only equal to an empty value
+		if !v.Valid || !other.Valid {
+			equal := !v.Valid && !other.Valid
+			switch n.op {
+			case "==":
+				return equal
+			case "!=":
+				return !equal
+			default:
+				return false
+			}
+		}
+		cmp = compareInts(int64(v.Duration), int64(other.Duration))
+	default:
+		return false
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,145 @@
+package filters
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+)
+
+func TestLexWhereStrings(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`"plain"`, `plain`},
+		{`"^guest-\d+$"`, `^guest-\d+$`},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`'^guest-\d+$'`, `^guest-\d+$`},
+		{`'it\'`, `it\`},
+	}
+
+	for _, tt := range tests {
+		tokens, err := lexWhere(tt.source)
+		if err != nil {
+			t.Errorf("lexWhere(%s): %v", tt.source, err)
+			continue
+		}
+		if len(tokens) != 2 || tokens[0].kind != tokString || tokens[1].kind != tokEOF {
+			t.Errorf("lexWhere(%s) = %v, want one string", tt.source, tokens)
+			continue
+		}
+		if tokens[0].value != tt.want {
+			t.Errorf("lexWhere(%s) = %q, want %q", tt.source, tokens[0].value, tt.want)
+		}
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	now := time.Now()
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	entry := &dpsk.Dpsk{
+		ID:           7,
+		User:         "guest-12",
+		WlansvcID:    3,
+		Mac:          dpsk.HardwareAddr(mac),
+		Expire:       dpsk.NewTimestamp(now.Add(72 * time.Hour)),
+		StartPoint:   dpsk.Never,
+		CurSharedNum: 2,
+	}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`user =~ "^guest-\d+$"`, true},
+		{`user =~ '^guest-\d+$'`, true},
+		{`user =~ "^guest-\\d+$"`, true}, // Doubled backslashes keep working
+		{`user =~ "^guest-\d\d$"`, true},
+		{`user =~ "^guest-\w+x$"`, false},
+		{`user !~ "^admin"`, true},
+		{`user == guest-12`, true},
+		{`user = "guest-12" && wlansvc-id == 3`, true},
+		{`wlansvc-id == 2 || id >= 7`, true},
+		{`!(id < 7)`, true},
+		{`NOT id > 7 AND user IS NOT EMPTY`, true},
+		{`mac == AA-BB-CC-DD-EE-FF`, true},
+		{`mac is empty`, false},
+		{`expire < now+7d && expire > now+2d`, true},
+		{`expire == never`, false},
+		{`start-point == never && start-point is empty`, true},
+		{`start-point < now || start-point > now`, false},
+		{`expires-in < 7d && expires-in > 2d`, true},
+		{`age is empty`, true},
+		{`shared && bound && !expired`, true},
+		{`used == false`, false},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseWhere(tt.source)
+		if err != nil {
+			t.Errorf("ParseWhere(%s): %v", tt.source, err)
+			continue
+		}
+		if got := expr.Match(entry); got != tt.want {
+			t.Errorf("ParseWhere(%s).Match() = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	for _, source := range []string{
+		``,
+		`user ==`,
+		`bogus == 1`,
+		`id == abc`,
+		`mac < aa:bb:cc:dd:ee:ff`,
+		`(id == 1`,
+		`user == "open`,
+		`user =~ "("`,
+		`expire < tomorrow`,
+		`expires-in > soon`,
+		`id == 1 id == 2`,
+	} {
+		if _, err := ParseWhere(source); err == nil {
+			t.Errorf("ParseWhere(%s) succeeded, want an error", source)
+		}
+	}
+}
+
+func TestParseTimeLiteral(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		literal string
+		want    time.Time
+	}{
+		{"now", now},
+		{"now+7d", now.Add(7 * 24 * time.Hour)},
+		{"now-1w2d", now.Add(-9 * 24 * time.Hour)},
+		{"now+1h30m", now.Add(90 * time.Minute)},
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)},
+		{"1700000000", time.Unix(1700000000, 0)},
+		{"2200000000", time.Unix(2200000000, 0)}, // After 2038
+		{"2030-05-01 10:00:00", time.Date(2030, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{"2024-06-01T10:00:00Z", time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeLiteral(tt.literal, now)
+		if err != nil {
+			t.Errorf("ParseTimeLiteral(%q): %v", tt.literal, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTimeLiteral(%q) = %v, want %v", tt.literal, got.Time, tt.want)
+		}
+	}
+
+	never, err := ParseTimeLiteral("never", now)
+	if err != nil || !never.IsNever() {
+		t.Errorf("ParseTimeLiteral(never) = %v, %v, want never", never, err)
+	}
+}
@@ -1,14 +1,18 @@
 package helpers
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
 	"time"
 )
 
 func ParseTimestamp(input string) (time.Time, error) {
 	// Try to parse as a Unix timestamp first
-	if timestamp, err := strconv.ParseInt(input, 10, 32); err == nil {
+	if timestamp, err := strconv.ParseInt(input, 10, 64); err == nil {
 		return time.Unix(timestamp, 0), nil
 	}
 
@@ -27,3 +31,28 @@ func ParseTimestamp(input string) (time.Time, error) {
 	}
 	return time.Time{}, fmt.Errorf("invalid input format")
 }
+
+// WithDeadline bounds ctx by the given duration, a zero or negative duration leaves it unbounded
+func WithDeadline(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// Confirm asks a yes/no question on out and reads the answer from in, anything but y/yes is a no
+func Confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,560 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Output formats
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatYAML     = "yaml"
+	FormatTemplate = "template"
+)
+
+// Usage describes the values accepted by -o
+const Usage = "Output format: table, json, ndjson, csv, yaml or template=<go template>, e.g. template='{{.User}} {{.Passphrase}}'"
+
+// Options selects how a list is written, see AddFlags
+type Options struct {
+	Format string
+	Fields string
+	Sort   string
+
+	// TableFields are the default columns of the table format, all fields when empty
+	TableFields []string
+
+	kind     string
+	template *template.Template
+	fields   []string
+	sortKeys []sortKey
+}
+
+type sortKey struct {
+	field      string
+	descending bool
+}
+
+// AddFlags registers -o, -fields and -sort on flagSet, tableFields are the default table columns
+func AddFlags(flagSet *flag.FlagSet, tableFields ...string) *Options {
+	options := &Options{TableFields: tableFields}
+	flagSet.StringVar(&options.Format, "o", FormatJSON, Usage)
+	flagSet.StringVar(&options.Fields, "fields", "", "Comma separated fields to output, e.g. id,user,expire")
+	flagSet.StringVar(&options.Sort, "sort", "", "Comma separated fields to sort by, prefix with - for descending order, e.g. user,-expire")
+	return options
+}
+
+// Validate checks the flag values, call it before doing any work so usage errors are reported early
+func (o *Options) Validate() error {
+	kind, text, _ := strings.Cut(o.Format, "=")
+	switch kind {
+	case FormatTable, FormatJSON, FormatNDJSON, FormatCSV, FormatYAML:
+		if text != "" {
+			return fmt.Errorf("output format %s does not take a value", kind)
+		}
+	case FormatTemplate:
+		if text == "" {
+			return fmt.Errorf("output format template requires a template, e.g. template='{{.ID}}'")
+		}
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid output template: %v", err)
+		}
+		o.template = tmpl
+	default:
+		return fmt.Errorf("unknown output format %q, valid formats: table, json, ndjson, csv, yaml, template=<tmpl>", o.Format)
+	}
+	o.kind = kind
+
+	o.fields = splitList(o.Fields)
+	if len(o.fields) > 0 && kind == FormatTemplate {
+		return fmt.Errorf("-fields cannot be used with a template, select the fields in the template")
+	}
+
+	o.sortKeys = nil
+	for _, field := range splitList(o.Sort) {
+		key := sortKey{field: field}
+		if strings.HasPrefix(field, "-") {
+			key = sortKey{field: field[1:], descending: true}
+		}
+		key.field = strings.TrimPrefix(key.field, "+")
+		if key.field == "" {
+			return fmt.Errorf("invalid sort field %q", field)
+		}
+		o.sortKeys = append(o.sortKeys, key)
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Render writes value, a map or slice of structs, in the selected format. Maps are ordered by key
+// and keep their keys in the json and yaml formats, the fields are named after their json tags.
+func (o *Options) Render(w io.Writer, value interface{}) error {
+	if o.kind == "" {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+
+	list, err := newList(value)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range append(append([]string{}, o.fields...), sortFields(o.sortKeys)...) {
+		if _, ok := list.fields[field]; !ok {
+			return fmt.Errorf("unknown field %q, valid fields: %s", field, strings.Join(list.names, ", "))
+		}
+	}
+
+	list.sort(o.sortKeys)
+
+	fields := o.fields
+	if len(fields) == 0 && o.kind == FormatTable && len(o.TableFields) > 0 {
+		fields = o.TableFields
+	}
+
+	switch o.kind {
+	case FormatTemplate:
+		return o.renderTemplate(w, list)
+	case FormatTable, FormatCSV:
+		if len(fields) == 0 {
+			fields = list.names
+		}
+		rows, err := list.rows(fields)
+		if err != nil {
+			return err
+		}
+		if o.kind == FormatCSV {
+			return renderCSV(w, fields, rows)
+		}
+		return renderTable(w, fields, rows)
+	}
+
+	nodes, err := list.nodes(fields)
+	if err != nil {
+		return err
+	}
+
+	switch o.kind {
+	case FormatNDJSON:
+		for _, node := range nodes {
+			if _, err := fmt.Fprintln(w, encodeJSON(node)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatYAML:
+		_, err := io.WriteString(w, encodeYAML(list.document(nodes)))
+		return err
+	default:
+		_, err := fmt.Fprintln(w, encodeJSON(list.document(nodes)))
+		return err
+	}
+}
+
+func sortFields(keys []sortKey) []string {
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, key.field)
+	}
+	return fields
+}
+
+func (o *Options) renderTemplate(w io.Writer, list *list) error {
+	for _, item := range list.items {
+		var buf bytes.Buffer
+		if err := o.template.Execute(&buf, item.Interface()); err != nil {
+			return fmt.Errorf("error executing output template: %v", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderCSV(w io.Writer, fields []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("error writing CSV: %v", err)
+	}
+	return nil
+}
+
+func renderTable(w io.Writer, fields []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = strings.ToUpper(field)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		// Tabs and line breaks inside a value would break the columns
+		for i, cell := range row {
+			row[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(cell)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// list holds the items to render in output order, along with the keys of a map
+type list struct {
+	keys   []reflect.Value // nil for slices
+	items  []reflect.Value
+	fields map[string][]int // field index paths by json name
+	names  []string         // json names in declaration order
+}
+
+func newList(value interface{}) (*list, error) {
+	v := reflect.ValueOf(value)
+	l := &list{}
+
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.SliceStable(keys, func(i, j int) bool {
+			return compareValues(keys[i].Interface(), keys[j].Interface()) < 0
+		})
+		l.keys = keys
+		for _, key := range keys {
+			l.items = append(l.items, v.MapIndex(key))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			l.items = append(l.items, v.Index(i))
+		}
+	default:
+		return nil, fmt.Errorf("unable to render %T, expected a map or a slice", value)
+	}
+
+	itemType := v.Type().Elem()
+	for itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to render %T, expected a list of structs", value)
+	}
+
+	l.fields = make(map[string][]int)
+	collectFields(itemType, nil, l.fields, map[string]int{}, &l.names)
+
+	return l, nil
+}
+
+// collectFields lists the fields encoding/json would output, including the ones promoted from
+// embedded structs. Shallower fields win over deeper ones with the same name.
+func collectFields(t reflect.Type, index []int, fields map[string][]int, depths map[string]int, names *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := append(append([]int{}, index...), i)
+
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if _, ok := reflect.PointerTo(fieldType).MethodByName("MarshalJSON"); !ok {
+				collectFields(fieldType, path, fields, depths, names)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if depth, ok := depths[name]; ok {
+			if depth <= len(path) {
+				continue
+			}
+		} else {
+			*names = append(*names, name)
+		}
+		depths[name] = len(path)
+		fields[name] = path
+	}
+}
+
+// value returns the typed value of a field, nil when an embedded pointer on the way is nil
+func (l *list) value(item reflect.Value, field string) interface{} {
+	v := item
+	for _, i := range l.fields[field] {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v.Interface()
+}
+
+func (l *list) sort(keys []sortKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	order := make([]int, len(l.items))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		for _, key := range keys {
+			cmp := compareValues(l.value(l.items[order[a]], key.field), l.value(l.items[order[b]], key.field))
+			if cmp == 0 {
+				continue
+			}
+			if key.descending {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+
+	items := make([]reflect.Value, len(order))
+	var mapKeys []reflect.Value
+	if l.keys != nil {
+		mapKeys = make([]reflect.Value, len(order))
+	}
+	for i, j := range order {
+		items[i] = l.items[j]
+		if mapKeys != nil {
+			mapKeys[i] = l.keys[j]
+		}
+	}
+	l.items, l.keys = items, mapKeys
+}
+
+// nodes encodes every item as JSON, keeping only fields when given
+func (l *list) nodes(fields []string) ([]interface{}, error) {
+	nodes := make([]interface{}, 0, len(l.items))
+	for _, item := range l.items {
+		data, err := json.Marshal(item.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		node, err := decodeJSON(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if obj, ok := node.(*object); ok && len(fields) > 0 {
+			node = obj.pick(fields)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// document returns the nodes as a JSON object keyed like the rendered map, or an array
+func (l *list) document(nodes []interface{}) interface{} {
+	if l.keys == nil {
+		return nodes
+	}
+
+	doc := &object{}
+	for i, key := range l.keys {
+		doc.set(fmt.Sprint(key.Interface()), nodes[i])
+	}
+	return doc
+}
+
+// rows returns the text of fields for every item, as shown in the JSON output
+func (l *list) rows(fields []string) ([][]string, error) {
+	nodes, err := l.nodes(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(nodes))
+	for _, node := range nodes {
+		obj, _ := node.(*object)
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			if value, ok := obj.get(field); ok {
+				row[i] = cellText(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func cellText(node interface{}) string {
+	switch v := node.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case []interface{}:
+		// Lists of plain values are joined, e.g. the WLAN ids of a role
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case *object, []interface{}:
+				return encodeJSON(v)
+			}
+			parts = append(parts, cellText(item))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return encodeJSON(v)
+	}
+}
+
+// Sortable values define their own order instead of their text, e.g. timestamps
+type Sortable interface {
+	SortKey() float64
+}
+
+// compareValues orders numbers numerically, booleans false first and anything else by its text.
+// Nil values go last.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	if sa, ok := a.(Sortable); ok {
+		if sb, ok := b.(Sortable); ok {
+			return compareFloats(sa.SortKey(), sb.SortKey())
+		}
+	}
+
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isInt(va) && isInt(vb):
+		return compareFloats(float64(va.Int()), float64(vb.Int()))
+	case isUint(va) && isUint(vb):
+		return compareFloats(float64(va.Uint()), float64(vb.Uint()))
+	case isFloat(va) && isFloat(vb):
+		return compareFloats(va.Float(), vb.Float())
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		return compareFloats(boolToFloat(va.Bool()), boolToFloat(vb.Bool()))
+	}
+
+	return strings.Compare(valueText(a), valueText(b))
+}
+
+func valueText(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return ""
+		}
+		return s.String()
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`{"b": 1, "a": {"z": true, "y": null}, "c": [1, "two", 3.50]}`, `{"b":1,"a":{"z":true,"y":null},"c":[1,"two",3.50]}`},
+		{`{"big": 12345678901234567890, "neg": -1e3}`, `{"big":12345678901234567890,"neg":-1e3}`},
+		{`{"html": "<a&b>", "quote": "say \"hi\"\n"}`, `{"html":"\u003ca\u0026b\u003e","quote":"say \"hi\"\n"}`}, // Escaped like json.Marshal
+		{`{"dup": 1, "other": 2, "dup": 3}`, `{"dup":3,"other":2}`},
+		{`[]`, `[]`},
+		{`{}`, `{}`},
+		{`"text"`, `"text"`},
+	}
+
+	for _, tt := range tests {
+		node, err := decodeJSON([]byte(tt.source))
+		if err != nil {
+			t.Errorf("decodeJSON(%s): %v", tt.source, err)
+			continue
+		}
+		if got := encodeJSON(node); got != tt.want {
+			t.Errorf("encodeJSON(%s) = %s, want %s", tt.source, got, tt.want)
+		}
+	}
+
+	for _, source := range []string{``, `{"a": }`, `[1, 2`, `{} {}`} {
+		if _, err := decodeJSON([]byte(source)); err == nil {
+			t.Errorf("decodeJSON(%s) succeeded, want an error", source)
+		}
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`{}`, "{}\n"},
+		{`[]`, "[]\n"},
+		{`"plain"`, "plain\n"},
+		{
+			`{"user": "guest-1", "id": 7, "shared": false, "expire": null}`,
+			"user: guest-1\nid: 7\nshared: false\nexpire: null\n",
+		},
+		{
+			// Strings YAML would read as another type are quoted
+			`{"a": "yes", "b": "No", "c": "null", "d": "123", "e": "", "f": "two words", "g": "a:b", "h": "-x", "i": "line\nbreak"}`,
+			"a: \"yes\"\nb: \"No\"\nc: \"null\"\nd: \"123\"\ne: \"\"\nf: \"two words\"\ng: \"a:b\"\nh: \"-x\"\ni: \"line\\nbreak\"\n",
+		},
+		{
+			`{"7": {"user": "a", "tags": ["x", "z"]}, "12": {"user": "b", "tags": []}}`,
+			"\"7\":\n  user: a\n  tags:\n    - x\n    - z\n\"12\":\n  user: b\n  tags: []\n",
+		},
+		{
+			`[{"id": 1, "clients": [{"mac": "aa:bb"}]}, {}, [1, 2], "s"]`,
+			"- id: 1\n  clients:\n    - mac: \"aa:bb\"\n- {}\n-\n  - 1\n  - 2\n- s\n",
+		},
+	}
+
+	for _, tt := range tests {
+		node, err := decodeJSON([]byte(tt.source))
+		if err != nil {
+			t.Fatalf("decodeJSON(%s): %v", tt.source, err)
+		}
+		if got := encodeYAML(node); got != tt.want {
+			t.Errorf("encodeYAML(%s) =\n%s\nwant\n%s", tt.source, got, tt.want)
+		}
+	}
+}
+
+type inner struct {
+	Note string `json:"note,omitempty"`
+}
+
+type item struct {
+	ID   int    `json:"id"`
+	User string `json:"user"`
+	*inner
+	Hidden string `json:"-"`
+}
+
+func TestRender(t *testing.T) {
+	value := map[int]*item{
+		12: {ID: 12, User: "bob", inner: &inner{Note: "vip"}},
+		7:  {ID: 7, User: "alice"},
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, `{"7":{"id":7,"user":"alice"},"12":{"id":12,"user":"bob","note":"vip"}}` + "\n"},
+		{[]string{"-o", "ndjson", "-sort", "-id"}, `{"id":12,"user":"bob","note":"vip"}` + "\n" + `{"id":7,"user":"alice"}` + "\n"},
+		{[]string{"-o", "json", "-fields", "user,id"}, `{"7":{"user":"alice","id":7},"12":{"user":"bob","id":12}}` + "\n"},
+		{[]string{"-o", "yaml", "-fields", "user,note", "-sort", "user"}, "\"7\":\n  user: alice\n\"12\":\n  user: bob\n  note: vip\n"},
+		{[]string{"-o", "csv", "-sort", "-user"}, "id,user,note\n12,bob,vip\n7,alice,\n"},
+		{[]string{"-o", "template={{.User}}={{.ID}}", "-sort", "id"}, "alice=7\nbob=12\n"},
+	}
+
+	for _, tt := range tests {
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		options := AddFlags(flagSet)
+		if err := flagSet.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := options.Render(&buf, value); err != nil {
+			t.Errorf("Render(%v): %v", tt.args, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("Render(%v) =\n%s\nwant\n%s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := [][]string{
+		{"-o", "xml"},
+		{"-fields", "bogus"},
+		{"-sort", "hidden"},
+		{"-o", "template={{.User"},
+	}
+
+	for _, args := range tests {
+		flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+		options := AddFlags(flagSet)
+		if err := flagSet.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := options.Render(io.Discard, []item{{ID: 1}}); err == nil {
+			t.Errorf("Render(%v) succeeded, want an error", args)
+		}
+	}
+}
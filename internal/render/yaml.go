@@ -0,0 +1,111 @@
+package render
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// encodeYAML writes a decoded JSON node as a block style YAML document. Strings are only left
+// unquoted when YAML cannot read them as another type, quoted ones use JSON escapes, which YAML
+// double quoted scalars share.
+func encodeYAML(node interface{}) string {
+	var b strings.Builder
+
+	switch v := node.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			return "{}\n"
+		}
+		writeYAMLObject(&b, v, 0, "")
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]\n"
+		}
+		writeYAMLList(&b, v, 0)
+	default:
+		b.WriteString(yamlScalar(v))
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// writeYAMLObject writes the keys at indent, the first one after prefix when the object is a list item
+func writeYAMLObject(b *strings.Builder, obj *object, indent int, prefix string) {
+	for i, key := range obj.keys {
+		if i == 0 && prefix != "" {
+			b.WriteString(prefix)
+		} else {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString(yamlScalar(key))
+		b.WriteByte(':')
+		writeYAMLValue(b, obj.values[key], indent+2)
+	}
+}
+
+func writeYAMLList(b *strings.Builder, list []interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, item := range list {
+		if obj, ok := item.(*object); ok && len(obj.keys) > 0 {
+			writeYAMLObject(b, obj, indent+2, pad+"- ")
+			continue
+		}
+		b.WriteString(pad)
+		b.WriteByte('-')
+		writeYAMLValue(b, item, indent+2)
+	}
+}
+
+// writeYAMLValue writes the value following a key or a list dash, nested blocks start at indent
+func writeYAMLValue(b *strings.Builder, node interface{}, indent int) {
+	switch v := node.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteByte('\n')
+		writeYAMLObject(b, v, indent, "")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteByte('\n')
+		writeYAMLList(b, v, indent)
+	default:
+		b.WriteByte(' ')
+		b.WriteString(yamlScalar(v))
+		b.WriteByte('\n')
+	}
+}
+
+var plainYAMLString = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./@+-]*$`)
+
+// yamlReserved are the plain scalars YAML 1.1 and 1.2 read as booleans or null
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true,
+}
+
+func yamlScalar(node interface{}) string {
+	switch v := node.(type) {
+	case string:
+		if plainYAMLString.MatchString(v) && !yamlReserved[strings.ToLower(v)] {
+			return v
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return "null"
+	}
+}
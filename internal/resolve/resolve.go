@@ -0,0 +1,28 @@
+// Package resolve turns human readable names given on the command line into controller ids
+package resolve
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/internal/filters"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+)
+
+// Wlan resolves a WLAN SSID or name to its wlansvc-id
+func Wlan(rc *client.Client) filters.NameResolver {
+	return func(ctx context.Context, name string) (string, error) {
+		id, err := rc.Wlan().ResolveID(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(id), nil
+	}
+}
+
+// Role resolves a role name to its role-id
+func Role(rc *client.Client) filters.NameResolver {
+	return func(ctx context.Context, name string) (string, error) {
+		return rc.Role().ResolveID(ctx, name)
+	}
+}
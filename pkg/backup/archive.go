@@ -0,0 +1,180 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/role"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/wlan"
+)
+
+// ConfigDir is the directory of a backup holding the configuration lists
+const ConfigDir = "etc/airespider"
+
+// Configuration lists stored in ConfigDir
+const (
+	DpskListFile = "dpsk-list.xml"
+	WlanListFile = "wlansvc-list.xml"
+	RoleListFile = "role-list.xml"
+)
+
+var (
+	ErrEncryptedBackup = errors.New("encrypted backups cannot be read")
+	ErrFileNotFound    = errors.New("file not found in backup")
+)
+
+// Archive is the content of a backup downloaded from _savebackup.jsp
+type Archive struct {
+	files map[string][]byte // Keyed by path inside the archive
+}
+
+func OpenArchive(path string) (*Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := ReadArchive(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return archive, nil
+}
+
+func ReadArchive(data []byte) (*Archive, error) {
+	format, err := client.ValidateBackup(data)
+	if err != nil {
+		return nil, err
+	}
+	if format != client.BackupFormatTarGzip {
+		return nil, ErrEncryptedBackup
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{files: make(map[string][]byte)}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		archive.files[path.Clean(hdr.Name)] = content
+	}
+
+	return archive, nil
+}
+
+// File returns the content of the file with the given path or base name. A base name is looked up in
+// ConfigDir first, then anywhere in the archive, failing when several files share it.
+func (a *Archive) File(name string) ([]byte, error) {
+	if content, ok := a.files[path.Clean(name)]; ok {
+		return content, nil
+	}
+
+	if content, ok := a.files[path.Join(ConfigDir, name)]; ok {
+		return content, nil
+	}
+
+	var matches []string
+	for filePath := range a.files {
+		if path.Base(filePath) == name {
+			matches = append(matches, filePath)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrFileNotFound, name)
+	case 1:
+		return a.files[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return nil, fmt.Errorf("ambiguous file name %s in backup: %s", name, strings.Join(matches, ", "))
+	}
+}
+
+func (a *Archive) Dpsk() (dpsk.Entries, error) {
+	data, err := a.File(DpskListFile)
+	if err != nil {
+		return nil, err
+	}
+	return dpsk.FromListXml(data)
+}
+
+func (a *Archive) Wlans() (wlan.Entries, error) {
+	data, err := a.File(WlanListFile)
+	if err != nil {
+		return nil, err
+	}
+	return wlan.FromListXml(data)
+}
+
+func (a *Archive) Roles() (role.Entries, error) {
+	data, err := a.File(RoleListFile)
+	if err != nil {
+		return nil, err
+	}
+	return role.FromListXml(data)
+}
+
+// ResolveWlan returns the wlansvc-id of the WLAN with the given SSID or name in the backup
+func (a *Archive) ResolveWlan(name string) (string, error) {
+	wlans, err := a.Wlans()
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := wlans.FindByName(name)
+	if err != nil {
+		if id, convErr := strconv.Atoi(name); convErr == nil {
+			if _, ok := wlans[id]; ok {
+				return name, nil
+			}
+		}
+		return "", err
+	}
+
+	return strconv.Itoa(entry.ID), nil
+}
+
+// ResolveRole returns the role-id of the role with the given name in the backup
+func (a *Archive) ResolveRole(name string) (string, error) {
+	roles, err := a.Roles()
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := roles.FindByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.ID, nil
+}
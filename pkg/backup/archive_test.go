@@ -0,0 +1,78 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newArchive builds a tar.gz backup holding the given files, keyed by path
+func newArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFile(t *testing.T) {
+	archive, err := ReadArchive(newArchive(t, map[string]string{
+		"./etc/airespider/dpsk-list.xml":        "current",
+		"etc/airespider-default/dpsk-list.xml":  "default",
+		"etc/airespider/system.xml":             "system",
+		"a/role-list.xml":                       "a",
+		"b/role-list.xml":                       "b",
+		"usr/share/" + strings.Repeat("x", 200): "padding",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"dpsk-list.xml", "current"},
+		{"etc/airespider-default/dpsk-list.xml", "default"},
+		{"./etc/airespider/system.xml", "system"},
+		{"system.xml", "system"},
+		{"a/role-list.xml", "a"},
+	}
+
+	for _, tt := range tests {
+		got, err := archive.File(tt.name)
+		if err != nil {
+			t.Errorf("File(%s): %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("File(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := archive.File("role-list.xml"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("File(role-list.xml) = %v, want an ambiguous name error", err)
+	}
+
+	if _, err := archive.File("wlansvc-list.xml"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("File(wlansvc-list.xml) = %v, want ErrFileNotFound", err)
+	}
+}
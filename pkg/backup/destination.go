@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("backup not found")
+
+// Object is a file stored in a Destination
+type Object struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// Destination stores backups and their manifests. Names are flat, relative to the destination.
+type Destination interface {
+	// Location returns a human readable address of name, e.g. a file path or URL
+	Location(name string) string
+	// Put stores data under name and verifies the stored copy
+	Put(ctx context.Context, name string, data []byte) error
+	// Get returns the contents of name, ErrNotFound if it does not exist
+	Get(ctx context.Context, name string) ([]byte, error)
+	List(ctx context.Context) ([]Object, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// ParseDestination returns an S3 destination for s3://bucket/prefix/ addresses and a local directory otherwise
+func ParseDestination(location string) (Destination, error) {
+	if strings.HasPrefix(location, "s3://") {
+		return NewS3Destination(location)
+	}
+	return &LocalDestination{Dir: location}, nil
+}
+
+// Save stores a backup and, when manifest is not nil, its manifest next to it
+func Save(ctx context.Context, dest Destination, name string, data []byte, manifest *Manifest) error {
+	if err := dest.Put(ctx, name, data); err != nil {
+		return fmt.Errorf("error saving %s: %v", dest.Location(name), err)
+	}
+
+	if manifest == nil {
+		return nil
+	}
+
+	encoded, err := manifest.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := dest.Put(ctx, ManifestPath(name), encoded); err != nil {
+		return fmt.Errorf("error saving %s: %v", dest.Location(ManifestPath(name)), err)
+	}
+
+	return nil
+}
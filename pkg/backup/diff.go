@@ -0,0 +1,292 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change statuses of a DiffEntry
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// AttributeChange is an attribute whose value differs between two backups
+type AttributeChange struct {
+	Attribute string `json:"attribute"`
+	Old       string `json:"old"`
+	New       string `json:"new"`
+}
+
+// DiffEntry is a configuration entry added, removed or changed between two backups
+type DiffEntry struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Status  string            `json:"status"`
+	Changes []AttributeChange `json:"changes,omitempty"`
+}
+
+// Skipped is a section left out of a diff because one of the backups has no readable list
+type Skipped struct {
+	Section string `json:"section"`
+	Reason  string `json:"reason"`
+}
+
+// Diff is the semantic difference between two backups, entries are matched by id
+type Diff struct {
+	Dpsk    []DiffEntry `json:"dpsk"`
+	Wlans   []DiffEntry `json:"wlan"`
+	Roles   []DiffEntry `json:"role"`
+	Skipped []Skipped   `json:"skipped,omitempty"`
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Dpsk) == 0 && len(d.Wlans) == 0 && len(d.Roles) == 0
+}
+
+// Compare returns the changes needed to go from the configuration in a to the one in b
+func Compare(a, b *Archive) (*Diff, error) {
+	diff := &Diff{}
+
+	dpskA, err := a.Dpsk()
+	if err != nil {
+		return nil, err
+	}
+	dpskB, err := b.Dpsk()
+	if err != nil {
+		return nil, err
+	}
+
+	oldEntries, newEntries := map[string]interface{}{}, map[string]interface{}{}
+	oldNames, newNames := map[string]string{}, map[string]string{}
+	for id, entry := range dpskA {
+		oldEntries[strconv.Itoa(id)], oldNames[strconv.Itoa(id)] = entry, entry.User
+	}
+	for id, entry := range dpskB {
+		newEntries[strconv.Itoa(id)], newNames[strconv.Itoa(id)] = entry, entry.User
+	}
+	if diff.Dpsk, err = compareEntries(oldEntries, newEntries, oldNames, newNames); err != nil {
+		return nil, err
+	}
+
+	// The DPSK list is required, WLAN and role lists are compared when both backups have them
+	wlansA, errA := a.Wlans()
+	wlansB, errB := b.Wlans()
+	if err := firstError(errA, errB); err != nil {
+		diff.Skipped = append(diff.Skipped, Skipped{Section: "wlan", Reason: err.Error()})
+	} else {
+		oldEntries, newEntries = map[string]interface{}{}, map[string]interface{}{}
+		oldNames, newNames = map[string]string{}, map[string]string{}
+		for id, entry := range wlansA {
+			oldEntries[strconv.Itoa(id)], oldNames[strconv.Itoa(id)] = entry, entry.SSID
+		}
+		for id, entry := range wlansB {
+			newEntries[strconv.Itoa(id)], newNames[strconv.Itoa(id)] = entry, entry.SSID
+		}
+		if diff.Wlans, err = compareEntries(oldEntries, newEntries, oldNames, newNames); err != nil {
+			return nil, err
+		}
+	}
+
+	rolesA, errA := a.Roles()
+	rolesB, errB := b.Roles()
+	if err := firstError(errA, errB); err != nil {
+		diff.Skipped = append(diff.Skipped, Skipped{Section: "role", Reason: err.Error()})
+	} else {
+		oldEntries, newEntries = map[string]interface{}{}, map[string]interface{}{}
+		oldNames, newNames = map[string]string{}, map[string]string{}
+		for id, entry := range rolesA {
+			oldEntries[id], oldNames[id] = entry, entry.Name
+		}
+		for id, entry := range rolesB {
+			newEntries[id], newNames[id] = entry, entry.Name
+		}
+		if diff.Roles, err = compareEntries(oldEntries, newEntries, oldNames, newNames); err != nil {
+			return nil, err
+		}
+	}
+
+	return diff, nil
+}
+
+// firstError describes the first backup whose list could not be read
+func firstError(errA, errB error) error {
+	if errA != nil {
+		return fmt.Errorf("old backup: %v", errA)
+	}
+	if errB != nil {
+		return fmt.Errorf("new backup: %v", errB)
+	}
+	return nil
+}
+
+func compareEntries(oldEntries, newEntries map[string]interface{}, oldNames, newNames map[string]string) ([]DiffEntry, error) {
+	ids := map[string]bool{}
+	for id := range oldEntries {
+		ids[id] = true
+	}
+	for id := range newEntries {
+		ids[id] = true
+	}
+
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return idLess(sorted[i], sorted[j])
+	})
+
+	result := []DiffEntry{}
+	for _, id := range sorted {
+		oldEntry, inOld := oldEntries[id]
+		newEntry, inNew := newEntries[id]
+
+		switch {
+		case !inOld:
+			result = append(result, DiffEntry{ID: id, Name: newNames[id], Status: Added})
+		case !inNew:
+			result = append(result, DiffEntry{ID: id, Name: oldNames[id], Status: Removed})
+		default:
+			changes, err := compareAttributes(oldEntry, newEntry)
+			if err != nil {
+				return nil, err
+			}
+			if len(changes) > 0 {
+				result = append(result, DiffEntry{ID: id, Name: newNames[id], Status: Changed, Changes: changes})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// compareAttributes compares entries attribute by attribute, using their JSON names and encoding
+func compareAttributes(oldEntry, newEntry interface{}) ([]AttributeChange, error) {
+	oldAttrs, err := attributes(oldEntry)
+	if err != nil {
+		return nil, err
+	}
+	newAttrs, err := attributes(newEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range oldAttrs {
+		names[name] = true
+	}
+	for name := range newAttrs {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []AttributeChange
+	for _, name := range sorted {
+		if oldAttrs[name] != newAttrs[name] {
+			changes = append(changes, AttributeChange{Attribute: name, Old: oldAttrs[name], New: newAttrs[name]})
+		}
+	}
+
+	return changes, nil
+}
+
+func attributes(entry interface{}) (map[string]string, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			attrs[name] = s
+		} else {
+			attrs[name] = string(value)
+		}
+	}
+
+	return attrs, nil
+}
+
+// idLess orders numeric ids numerically and any other id alphabetically
+func idLess(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// WriteText prints the diff in a human readable form
+func (d *Diff) WriteText(w io.Writer) {
+	for _, section := range []struct {
+		title   string
+		entries []DiffEntry
+	}{
+		{"DPSK", d.Dpsk},
+		{"WLAN", d.Wlans},
+		{"Role", d.Roles},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s:\n", section.title)
+		for _, entry := range section.entries {
+			mark := map[string]string{Added: "+", Removed: "-", Changed: "~"}[entry.Status]
+			fmt.Fprintf(w, "  %s %s %s\n", mark, entry.ID, entry.Name)
+
+			for _, change := range entry.Changes {
+				fmt.Fprintf(w, "      %s: %s -> %s\n", change.Attribute, quote(change.Old), quote(change.New))
+			}
+		}
+	}
+
+	for _, skipped := range d.Skipped {
+		fmt.Fprintf(w, "%s list not compared: %s\n", skipped.Section, skipped.Reason)
+	}
+
+	if d.Empty() {
+		fmt.Fprintln(w, "No differences")
+		return
+	}
+
+	fmt.Fprintf(w, "%d DPSK, %d WLAN and %d role differences\n", len(d.Dpsk), len(d.Wlans), len(d.Roles))
+}
+
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// MaskSecrets replaces passphrase values so the diff can be shared
+func (d *Diff) MaskSecrets() {
+	for i := range d.Dpsk {
+		for j := range d.Dpsk[i].Changes {
+			if d.Dpsk[i].Changes[j].Attribute == "passphrase" {
+				d.Dpsk[i].Changes[j].Old = "***"
+				d.Dpsk[i].Changes[j].New = "***"
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LocalDestination stores backups in a directory of the local filesystem
+type LocalDestination struct {
+	Dir string
+}
+
+func (d *LocalDestination) Location(name string) string {
+	return filepath.Join(d.Dir, name)
+}
+
+func (d *LocalDestination) Put(ctx context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return fmt.Errorf("error creating backup directory: %v", err)
+	}
+
+	// Write to a temporary file first so an interrupted write never leaves a truncated backup behind
+	tmp, err := os.CreateTemp(d.Dir, ".backup-*")
+	if err != nil {
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("error setting file permissions: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), d.Location(name)); err != nil {
+		return fmt.Errorf("error saving file: %v", err)
+	}
+
+	// Read the file back to make sure what is on disk is what was downloaded
+	stored, err := os.ReadFile(d.Location(name))
+	if err != nil {
+		return fmt.Errorf("error verifying file: %v", err)
+	}
+	if !bytes.Equal(stored, data) {
+		return fmt.Errorf("stored file does not match the data written")
+	}
+
+	return nil
+}
+
+func (d *LocalDestination) Get(ctx context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(d.Location(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, d.Location(name))
+	}
+	return data, err
+}
+
+func (d *LocalDestination) List(ctx context.Context) ([]Object, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup directory: %v", err)
+	}
+
+	objects := []Object{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", d.Location(entry.Name()), err)
+		}
+
+		objects = append(objects, Object{Name: entry.Name(), Size: info.Size(), Modified: info.ModTime()})
+	}
+
+	return objects, nil
+}
+
+func (d *LocalDestination) Delete(ctx context.Context, name string) error {
+	if err := os.Remove(d.Location(name)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotFound, d.Location(name))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package backup works with controller configuration backups without contacting the controller
+package backup
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ManifestSuffix is appended to the backup file name to build the sidecar manifest name
+const ManifestSuffix = ".manifest.json"
+
+var (
+	ErrChecksumMismatch = errors.New("backup does not match its manifest")
+	ErrInvalidSignature = errors.New("invalid manifest signature")
+	ErrUnsigned         = errors.New("manifest is not signed")
+)
+
+// Manifest describes a backup so it can be checked before it is restored
+type Manifest struct {
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Format     string    `json:"format"`
+	Controller string    `json:"controller"`
+	Firmware   string    `json:"firmware,omitempty"`
+	Created    time.Time `json:"created"`
+	Signature  string    `json:"signature,omitempty"` // base64 ed25519 signature of the manifest without this field
+}
+
+func NewManifest(data []byte, format string, controller string, firmware string) *Manifest {
+	sum := sha256.Sum256(data)
+	return &Manifest{
+		Size:       int64(len(data)),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Format:     format,
+		Controller: controller,
+		Firmware:   firmware,
+		Created:    time.Now().UTC(),
+	}
+}
+
+func ManifestPath(backupPath string) string {
+	return backupPath + ManifestSuffix
+}
+
+// signedPayload is the canonical encoding covered by the signature
+func (m *Manifest) signedPayload() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+func (m *Manifest) Sign(key ed25519.PrivateKey) error {
+	payload, err := m.signedPayload()
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %v", err)
+	}
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return nil
+}
+
+// VerifySignature checks the manifest was signed by the private key matching key
+func (m *Manifest) VerifySignature(key ed25519.PublicKey) error {
+	if m.Signature == "" {
+		return ErrUnsigned
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	payload, err := m.signedPayload()
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %v", err)
+	}
+
+	if !ed25519.Verify(key, payload, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Check verifies that data is the backup described by the manifest
+func (m *Manifest) Check(data []byte) error {
+	if int64(len(data)) != m.Size {
+		return fmt.Errorf("%w: size is %d bytes, expected %d", ErrChecksumMismatch, len(data), m.Size)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != m.SHA256 {
+		return fmt.Errorf("%w: sha256 is %x, expected %s", ErrChecksumMismatch, sum, m.SHA256)
+	}
+	return nil
+}
+
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding manifest: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func (m *Manifest) WriteFile(path string) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	return nil
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %v", err)
+	}
+	return &m, nil
+}
+
+// GenerateKey creates an ed25519 key pair and writes it as PEM files, the private key with 0600 permissions
+func GenerateKey(privatePath string, publicPath string) error {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return fmt.Errorf("error generating key: %v", err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("error encoding private key: %v", err)
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("error encoding public key: %v", err)
+	}
+
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600); err != nil {
+		return fmt.Errorf("error writing private key: %v", err)
+	}
+
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644); err != nil {
+		return fmt.Errorf("error writing public key: %v", err)
+	}
+
+	return nil
+}
+
+func ReadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key %s: %v", path, err)
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an ed25519 key", path)
+	}
+	return priv, nil
+}
+
+func ReadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key %s: %v", path, err)
+	}
+
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key: %v", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
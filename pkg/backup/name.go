@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultNameTemplate names backups saved in directory mode
+const DefaultNameTemplate = `{{.Host}}-{{.Time | date "20060102-150405"}}.bak`
+
+// NameData is the data available to backup file name templates
+type NameData struct {
+	Host string    // Controller host name, without scheme or port
+	Time time.Time // Time the backup was taken, in local time
+}
+
+// NameTemplate builds backup file names, e.g. {{.Host}}-{{.Time | date "2006-01-02"}}.bak
+type NameTemplate struct {
+	tmpl *template.Template
+}
+
+func ParseNameTemplate(text string) (*NameTemplate, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Funcs(template.FuncMap{
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"utc": func(t time.Time) time.Time {
+			return t.UTC()
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name template: %v", err)
+	}
+
+	return &NameTemplate{tmpl: tmpl}, nil
+}
+
+// Name renders the template, rejecting results that would escape the target directory
+func (t *NameTemplate) Name(data NameData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error rendering file name template: %v", err)
+	}
+
+	name := buf.String()
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return "", fmt.Errorf("file name template produced an invalid file name %q", name)
+	}
+
+	return name, nil
+}
+
+// HostName extracts the host part of a controller address for use in file names
+func HostName(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return strings.NewReplacer("/", "_", ":", "_", `\`, "_").Replace(server)
+}
@@ -0,0 +1,153 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy is a grandfather-father-son rotation scheme. For each period the newest backup of
+// the most recent Daily days, Weekly ISO weeks and Monthly months is kept, plus the Last newest backups.
+type RetentionPolicy struct {
+	Last    int
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// Item is a backup considered by a RetentionPolicy
+type Item struct {
+	Name string
+	Time time.Time
+}
+
+func (p RetentionPolicy) Empty() bool {
+	return p.Last <= 0 && p.Daily <= 0 && p.Weekly <= 0 && p.Monthly <= 0
+}
+
+func (p RetentionPolicy) Validate() error {
+	if p.Last < 0 || p.Daily < 0 || p.Weekly < 0 || p.Monthly < 0 {
+		return fmt.Errorf("retention counts cannot be negative")
+	}
+	if p.Empty() {
+		return fmt.Errorf("retention policy would remove every backup, keep at least one")
+	}
+	return nil
+}
+
+// Apply splits items into the ones to keep and the ones to remove, both sorted newest first.
+// Periods are computed in the location of each item time.
+func (p RetentionPolicy) Apply(items []Item) (keep []Item, remove []Item) {
+	sorted := append([]Item(nil), items...)
+	// Names usually embed the time, so they break ties between backups taken within the clock resolution
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].Time.After(sorted[j].Time)
+		}
+		return sorted[i].Name > sorted[j].Name
+	})
+
+	kept := make([]bool, len(sorted))
+
+	// The newest backup of each period wins, older ones in the same period are candidates for removal
+	bucket := func(count int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for i, item := range sorted {
+			if len(seen) >= count {
+				return
+			}
+			key := period(item.Time)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kept[i] = true
+		}
+	}
+
+	bucket(p.Last, func(t time.Time) string {
+		return t.Format(time.RFC3339Nano)
+	})
+	bucket(p.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	bucket(p.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	bucket(p.Monthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	for i, item := range sorted {
+		if kept[i] {
+			keep = append(keep, item)
+		} else {
+			remove = append(remove, item)
+		}
+	}
+
+	return keep, remove
+}
+
+// Scan lists the backups in dest whose name matches pattern. The backup time is read from the
+// manifest when there is one, since copying or uploading files does not preserve modification times.
+func Scan(ctx context.Context, dest Destination, pattern string) ([]Item, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	objects, err := dest.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, object := range objects {
+		names[object.Name] = true
+	}
+
+	items := []Item{}
+	for _, object := range objects {
+		if strings.HasSuffix(object.Name, ManifestSuffix) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, object.Name); !ok {
+			continue
+		}
+
+		item := Item{Name: object.Name, Time: object.Modified}
+
+		if names[ManifestPath(object.Name)] {
+			data, err := dest.Get(ctx, ManifestPath(object.Name))
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s: %v", dest.Location(ManifestPath(object.Name)), err)
+			}
+
+			manifest, err := ParseManifest(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", dest.Location(ManifestPath(object.Name)), err)
+			}
+			item.Time = manifest.Created.Local()
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Remove deletes a backup together with its manifest
+func Remove(ctx context.Context, dest Destination, name string) error {
+	if err := dest.Delete(ctx, name); err != nil {
+		return fmt.Errorf("error removing %s: %v", dest.Location(name), err)
+	}
+	if err := dest.Delete(ctx, ManifestPath(name)); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("error removing %s: %v", dest.Location(ManifestPath(name)), err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyApply(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	// Two backups a day from Friday 2024-04-26 to Friday 2024-05-10, newest first: 0510-18, 0510-06, ...
+	var items []Item
+	for day := time.Date(2024, 4, 26, 0, 0, 0, 0, time.UTC); !day.After(at(10, 0)); day = day.AddDate(0, 0, 1) {
+		for _, hour := range []int{6, 18} {
+			t := day.Add(time.Duration(hour) * time.Hour)
+			items = append(items, Item{Name: t.Format("0102-15"), Time: t})
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		keep   []string
+	}{
+		{"last", RetentionPolicy{Last: 3}, []string{"0510-18", "0510-06", "0509-18"}},
+		{"daily", RetentionPolicy{Daily: 3}, []string{"0510-18", "0509-18", "0508-18"}},
+		// ISO weeks start on Monday: 05-06..05-12, 04-29..05-05, 04-22..04-28
+		{"weekly", RetentionPolicy{Weekly: 5}, []string{"0510-18", "0505-18", "0428-18"}},
+		{"monthly", RetentionPolicy{Monthly: 2}, []string{"0510-18", "0430-18"}},
+		{"combined", RetentionPolicy{Last: 2, Daily: 2, Monthly: 2}, []string{"0510-18", "0510-06", "0509-18", "0430-18"}},
+		{"more than available", RetentionPolicy{Last: 100}, nil},
+	}
+
+	for _, tt := range tests {
+		keep, remove := tt.policy.Apply(items)
+
+		var got []string
+		for _, item := range keep {
+			got = append(got, item.Name)
+		}
+
+		if tt.keep == nil {
+			if len(keep) != len(items) || len(remove) != 0 {
+				t.Errorf("%s: kept %d and removed %d of %d", tt.name, len(keep), len(remove), len(items))
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.keep) {
+			t.Errorf("%s: kept %v, want %v", tt.name, got, tt.keep)
+		}
+		if len(keep)+len(remove) != len(items) {
+			t.Errorf("%s: kept %d and removed %d of %d", tt.name, len(keep), len(remove), len(items))
+		}
+		for i := 1; i < len(remove); i++ {
+			if remove[i].Time.After(remove[i-1].Time) {
+				t.Errorf("%s: removed items are not sorted newest first", tt.name)
+				break
+			}
+		}
+	}
+}
+
+func TestRetentionPolicyApplyTies(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	items := []Item{{Name: "b", Time: now}, {Name: "c", Time: now}, {Name: "a", Time: now.Add(-time.Hour)}}
+
+	keep, remove := RetentionPolicy{Daily: 1}.Apply(items)
+	if len(keep) != 1 || keep[0].Name != "c" {
+		t.Errorf("kept %v, want c, names break ties", keep)
+	}
+	if len(remove) != 2 || remove[0].Name != "b" || remove[1].Name != "a" {
+		t.Errorf("removed %v, want b and a", remove)
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy RetentionPolicy
+		valid  bool
+	}{
+		{RetentionPolicy{Last: 1}, true},
+		{RetentionPolicy{Daily: 7, Weekly: 4, Monthly: 12}, true},
+		{RetentionPolicy{}, false},
+		{RetentionPolicy{Last: 1, Daily: -1}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid %v", tt.policy, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Destination stores backups in a bucket of an S3-compatible object storage such as AWS S3 or MinIO
+type S3Destination struct {
+	Endpoint    *url.URL // Custom endpoint, e.g. http://localhost:9000 for MinIO. Nil uses AWS.
+	Bucket      string
+	Prefix      string // Prepended to every name, ends with a slash unless empty
+	Region      string
+	Credentials S3Credentials
+	Client      *http.Client
+}
+
+// S3Error is an error response from the object storage
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("object storage request failed with status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("object storage request failed with status code %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// NewS3Destination builds a destination from an s3://bucket/prefix/ address. Credentials, region and
+// endpoint are read from the standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+// AWS_REGION (or AWS_DEFAULT_REGION) and AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) environment variables.
+func NewS3Destination(location string) (*S3Destination, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 address %q, expected s3://bucket/prefix/", location)
+	}
+
+	prefix := strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	dest := &S3Destination{
+		Bucket: u.Host,
+		Prefix: prefix,
+		Region: firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		Credentials: S3Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		Client: &http.Client{Timeout: 5 * time.Minute},
+	}
+
+	if dest.Region == "" {
+		dest.Region = "us-east-1"
+	}
+
+	if dest.Credentials.AccessKeyID == "" || dest.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for %s", location)
+	}
+
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		dest.Endpoint, err = url.Parse(endpoint)
+		if err != nil || dest.Endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+	}
+
+	return dest, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (d *S3Destination) Location(name string) string {
+	return "s3://" + d.Bucket + "/" + d.Prefix + name
+}
+
+// url addresses key with path-style requests on custom endpoints, which MinIO expects,
+// and virtual-hosted-style requests on AWS
+func (d *S3Destination) url(key string, query url.Values) *url.URL {
+	u := &url.URL{Scheme: "https", Host: "s3." + d.Region + ".amazonaws.com"}
+	path := "/" + key
+
+	if d.Endpoint != nil {
+		u.Scheme = d.Endpoint.Scheme
+		u.Host = d.Endpoint.Host
+		path = strings.TrimSuffix(d.Endpoint.Path, "/") + "/" + d.Bucket + path
+	} else {
+		u.Host = d.Bucket + "." + u.Host
+	}
+
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = query.Encode()
+	return u
+}
+
+func (d *S3Destination) do(ctx context.Context, method string, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, d.url(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	signV4(req, hashHex(body), d.Credentials, d.Region, "s3", time.Now())
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		s3err := &S3Error{StatusCode: resp.StatusCode}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil {
+			xml.Unmarshal(data, s3err)
+		}
+		return nil, s3err
+	}
+
+	return resp, nil
+}
+
+// Put uploads data and checks the stored object size and checksum
+func (d *S3Destination) Put(ctx context.Context, name string, data []byte) error {
+	sum := md5.Sum(data)
+
+	// The storage rejects the upload if the body does not match Content-MD5 or the signed SHA-256
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+
+	resp, err := d.do(ctx, http.MethodPut, d.Prefix+name, nil, data, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// Check the object is there and is what was uploaded
+	resp, err = d.do(ctx, http.MethodHead, d.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("error verifying upload: %v", err)
+	}
+	resp.Body.Close()
+
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err != nil || size != int64(len(data)) {
+		return fmt.Errorf("error verifying upload: stored object has %s bytes, expected %d", resp.Header.Get("Content-Length"), len(data))
+	}
+
+	// Single part uploads without KMS encryption use the MD5 as ETag, other ETags are opaque
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if len(etag) == 32 && !strings.EqualFold(etag, hex.EncodeToString(sum[:])) {
+		if _, err := hex.DecodeString(etag); err == nil {
+			return fmt.Errorf("error verifying upload: stored object ETag %s does not match MD5 %x", etag, sum)
+		}
+	}
+
+	return nil
+}
+
+func (d *S3Destination) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := d.do(ctx, http.MethodGet, d.Prefix+name, nil, nil, nil)
+	if err != nil {
+		if s3err, ok := err.(*S3Error); ok && s3err.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, d.Location(name))
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List returns the objects directly under the prefix, objects in deeper "directories" are skipped
+func (d *S3Destination) List(ctx context.Context) ([]Object, error) {
+	objects := []Object{}
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", d.Prefix)
+		query.Set("delimiter", "/")
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := d.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %v", d.Location(""), err)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %v", d.Location(""), err)
+		}
+
+		var result listBucketResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("error decoding bucket listing: %v", err)
+		}
+
+		for _, c := range result.Contents {
+			name := strings.TrimPrefix(c.Key, d.Prefix)
+			if name == "" || strings.Contains(name, "/") {
+				continue
+			}
+			objects = append(objects, Object{Name: name, Size: c.Size, Modified: c.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (d *S3Destination) Delete(ctx context.Context, name string) error {
+	resp, err := d.do(ctx, http.MethodDelete, d.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3DestinationList(t *testing.T) {
+	// Pages of the listing, keyed by the continuation token that requests them
+	pages := map[string]struct {
+		keys []string
+		next string
+	}{
+		"":   {keys: []string{"backups/a.bak", "backups/a.bak.manifest.json"}, next: "t1"},
+		"t1": {keys: []string{"backups/b.bak", "backups/old/c.bak"}, next: "t2"},
+		"t2": {keys: []string{"backups/d.bak"}},
+	}
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/bucket/" || query.Get("list-type") != "2" || query.Get("prefix") != "backups/" || query.Get("delimiter") != "/" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			http.Error(w, "unsigned request", http.StatusForbidden)
+			return
+		}
+
+		token := query.Get("continuation-token")
+		tokens = append(tokens, token)
+		page, ok := pages[token]
+		if !ok {
+			http.Error(w, "unknown token", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintf(w, "<ListBucketResult><IsTruncated>%v</IsTruncated>", page.next != "")
+		if page.next != "" {
+			fmt.Fprintf(w, "<NextContinuationToken>%s</NextContinuationToken>", page.next)
+		}
+		for _, key := range page.keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2024-05-10T12:00:00.000Z</LastModified></Contents>", key, len(key))
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	}))
+	defer server.Close()
+
+	endpoint, _ := url.Parse(server.URL)
+	dest := &S3Destination{
+		Endpoint:    endpoint,
+		Bucket:      "bucket",
+		Prefix:      "backups/",
+		Region:      "us-east-1",
+		Credentials: S3Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+	}
+
+	objects, err := dest.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modified := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	want := []Object{
+		{Name: "a.bak", Size: 13, Modified: modified},
+		{Name: "a.bak.manifest.json", Size: 27, Modified: modified},
+		{Name: "b.bak", Size: 13, Modified: modified},
+		{Name: "d.bak", Size: 13, Modified: modified},
+	}
+	if !reflect.DeepEqual(objects, want) {
+		t.Errorf("List() = %v, want %v", objects, want)
+	}
+
+	if want := []string{"", "t1", "t2"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("continuation tokens = %q, want %q", tokens, want)
+	}
+}
+
+func TestS3DestinationListError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+	}))
+	defer server.Close()
+
+	endpoint, _ := url.Parse(server.URL)
+	dest := &S3Destination{Endpoint: endpoint, Bucket: "bucket", Region: "us-east-1"}
+
+	_, err := dest.List(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "403: AccessDenied: Access Denied") {
+		t.Errorf("List() = %v, want an AccessDenied error", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package ajax implements the ajax-request/ajax-response XML protocol spoken by
+// Ruckus Unleashed and ZoneDirector controllers.
+package ajax
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionGetStat Action = "getstat" // Read runtime status, served by _cmdstat.jsp
+	ActionDoCmd   Action = "docmd"   // Execute a command, served by _cmdstat.jsp
+	ActionGetConf Action = "getconf" // Read configuration objects, served by _conf.jsp
+	ActionUpdObj  Action = "updobj"  // Update a configuration object, served by _conf.jsp
+	ActionDelObj  Action = "delobj"  // Delete a configuration object, served by _conf.jsp
+)
+
+const (
+	PathCmdStat = "/admin/_cmdstat.jsp"
+	PathConf    = "/admin/_conf.jsp"
+)
+
+// Path returns the controller endpoint that handles the action
+func (a Action) Path() string {
+	switch a {
+	case ActionGetStat, ActionDoCmd:
+		return PathCmdStat
+	default:
+		return PathConf
+	}
+}
+
+// Idempotent reports whether repeating the action has the same effect as sending it once.
+// docmd is excluded since commands such as batch-dpsk generate new objects every time, delobj
+// since repeating it after a lost response fails on the objects the first attempt deleted.
+func (a Action) Idempotent() bool {
+	return a != ActionDoCmd && a != ActionDelObj
+}
+
+// Mutating reports whether the action changes controller state
+func (a Action) Mutating() bool {
+	switch a {
+	case ActionDoCmd, ActionUpdObj, ActionDelObj:
+		return true
+	default:
+		return false
+	}
+}
+
+// Request is an ajax-request envelope. Use the action specific constructors to build one.
+type Request struct {
+	XMLName      xml.Name  `xml:"ajax-request"`
+	Action       Action    `xml:"action,attr"`
+	CheckAbility string    `xml:"checkAbility,attr,omitempty"`
+	Updater      string    `xml:"updater,attr"`
+	Comp         string    `xml:"comp,attr"`
+	Elements     []Element `xml:",any"`
+}
+
+func newRequest(action Action, comp string, updater string, elements ...Element) *Request {
+	return &Request{
+		Action:   action,
+		Updater:  updater + "." + Timestamp(time.Now()),
+		Comp:     comp,
+		Elements: elements,
+	}
+}
+
+// GetStat builds a getstat request, e.g. GetStat("stamgr", "dpsk-list", NewElement("dpsklist"))
+func GetStat(comp string, updater string, elements ...Element) *Request {
+	return newRequest(ActionGetStat, comp, updater, elements...)
+}
+
+// DoCmd builds a docmd request wrapping a single xcmd element
+func DoCmd(comp string, xcmd Element) *Request {
+	req := newRequest(ActionDoCmd, comp, comp, xcmd)
+	req.CheckAbility = "2"
+	return req
+}
+
+// GetConf builds a getconf request for the given configuration objects
+func GetConf(comp string, elements ...Element) *Request {
+	return newRequest(ActionGetConf, comp, comp, elements...)
+}
+
+// UpdObj builds an updobj request for a single configuration object
+func UpdObj(comp string, obj Element) *Request {
+	return newRequest(ActionUpdObj, comp, comp, obj)
+}
+
+// DelObj builds a delobj request for one or more configuration objects
+func DelObj(comp string, objs ...Element) *Request {
+	return newRequest(ActionDelObj, comp, comp, objs...)
+}
+
+// Path returns the controller endpoint that handles the request
+func (r *Request) Path() string {
+	return r.Action.Path()
+}
+
+// Marshal encodes the request, escaping every attribute value
+func (r *Request) Marshal() ([]byte, error) {
+	data, err := xml.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding ajax request: %v", err)
+	}
+	return data, nil
+}
+
+// Element is a generic XML element whose attributes keep their insertion order
+type Element struct {
+	Name     string
+	Attrs    []xml.Attr
+	Children []Element
+}
+
+func NewElement(name string, attrs ...xml.Attr) Element {
+	return Element{Name: name, Attrs: attrs}
+}
+
+// XCmd builds the xcmd element used by docmd requests
+func XCmd(cmd string, attrs ...xml.Attr) Element {
+	return NewElement("xcmd", append([]xml.Attr{Attr("cmd", cmd)}, attrs...)...)
+}
+
+func Attr(name string, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
+
+// Set appends an attribute, replacing any previous value with the same name
+func (e *Element) Set(name string, value string) {
+	for i, attr := range e.Attrs {
+		if attr.Name.Local == name {
+			e.Attrs[i].Value = value
+			return
+		}
+	}
+	e.Attrs = append(e.Attrs, Attr(name, value))
+}
+
+// Get returns the value of an attribute
+func (e *Element) Get(name string) (string, bool) {
+	for _, attr := range e.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Child returns the first direct child with the given name
+func (e *Element) Child(name string) (Element, bool) {
+	for _, child := range e.Children {
+		if child.Name == name {
+			return child, true
+		}
+	}
+	return Element{}, false
+}
+
+// AttrMap returns the attributes as a map, later duplicates win
+func (e *Element) AttrMap() map[string]string {
+	result := make(map[string]string, len(e.Attrs))
+	for _, attr := range e.Attrs {
+		result[attr.Name.Local] = attr.Value
+	}
+	return result
+}
+
+func (e Element) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: e.Name}, Attr: e.Attrs}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, child := range e.Children {
+		if err := enc.Encode(child); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// Timestamp formats t the way the controller web UI builds updater ids: milliseconds.microseconds
+func Timestamp(t time.Time) string {
+	nanos := t.UnixNano()
+
+	milliseconds := nanos / int64(time.Millisecond)
+	microseconds := nanos / int64(time.Microsecond)
+
+	// Calculate the fractional part (microseconds) by subtracting milliseconds
+	fractionalPart := microseconds - (milliseconds * 1000)
+
+	return fmt.Sprintf("%d.%04d", milliseconds, fractionalPart)
+}
+
+func (e *Element) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	e.Name = start.Name.Local
+	e.Attrs = append([]xml.Attr(nil), start.Attr...)
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var child Element
+			if err := dec.DecodeElement(&child, &t); err != nil {
+				return err
+			}
+			e.Children = append(e.Children, child)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// ParseRequest decodes an ajax-request document, as received by a controller
+func ParseRequest(data []byte) (*Request, error) {
+	var req Request
+	if err := xml.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("error unmarshalling ajax request: %v", err)
+	}
+	return &req, nil
+}
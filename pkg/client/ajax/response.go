@@ -0,0 +1,82 @@
+package ajax
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Response is a parsed ajax-response envelope
+type Response struct {
+	XMLName xml.Name `xml:"ajax-response"`
+	Body    Body     `xml:"response"`
+	Error   *Error   `xml:"error"` // Some firmwares report failures outside of the response element
+	Raw     []byte   `xml:"-"`     // Full response document as received
+}
+
+type Body struct {
+	Type  string `xml:"type,attr"`
+	ID    string `xml:"id,attr"`
+	XMsg  *XMsg  `xml:"xmsg"`
+	Error *Error `xml:"error"`
+	Inner []byte `xml:",innerxml"`
+}
+
+// XMsg is the status element returned by docmd requests. A non zero status means the command failed.
+type XMsg struct {
+	Status string `xml:"status,attr"`
+	LMsg   string `xml:"lmsg,attr"`
+	Msg    string `xml:"msg,attr"`
+}
+
+// Error is the error element the controller embeds when it rejects a request
+type Error struct {
+	Code string `xml:"code,attr"`
+	Msg  string `xml:"msg,attr"`
+	Text string `xml:",chardata"`
+}
+
+// Failure reports whether the controller rejected the request, returning its error code and message
+func (r *Response) Failure() (code string, message string, failed bool) {
+	for _, e := range []*Error{r.Error, r.Body.Error} {
+		if e != nil {
+			return e.Code, firstNonEmpty(e.Msg, strings.TrimSpace(e.Text), "unknown error"), true
+		}
+	}
+
+	if x := r.Body.XMsg; x != nil && x.Status != "" && x.Status != "0" {
+		return x.Status, firstNonEmpty(x.LMsg, x.Msg, "unknown error"), true
+	}
+
+	if r.Body.Type == "error" {
+		return "", firstNonEmpty(strings.TrimSpace(string(r.Body.Inner)), "unknown error"), true
+	}
+
+	return "", "", false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func ParseResponse(data []byte) (*Response, error) {
+	var resp Response
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("error unmarshalling ajax response: %v", err)
+	}
+	resp.Raw = data
+	return &resp, nil
+}
+
+// Decode unmarshals the full response document into v, which must match the ajax-response root
+func (r *Response) Decode(v any) error {
+	if err := xml.Unmarshal(r.Raw, v); err != nil {
+		return fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+	return nil
+}
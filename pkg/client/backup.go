@@ -0,0 +1,387 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+)
+
+const (
+	backupPath  = "/admin/webPage/system/admin/_savebackup.jsp"
+	restorePath = "/admin/webPage/system/admin/_restore.jsp"
+
+	// MinBackupSize is the smallest payload accepted as a controller backup
+	MinBackupSize = 128
+	// MaxBackupSize is the largest payload accepted as a controller backup
+	MaxBackupSize = 256 << 20
+)
+
+type BackupFormat string
+
+const (
+	BackupFormatTarGzip   BackupFormat = "tar.gz"    // Plain gzip compressed tar archive
+	BackupFormatEncrypted BackupFormat = "encrypted" // Archive encrypted by the firmware, see encryptedBackupHeader
+)
+
+// encryptedBackupHeader starts the encrypted archives some firmwares produce: the OpenSSL salted
+// format, followed by an 8 byte salt and the AES-CBC ciphertext
+var encryptedBackupHeader = []byte("Salted__")
+
+// ErrInvalidBackup is returned when a payload does not look like a controller backup
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// ErrRestoreInProgress is returned when the connection is lost or the controller fails after the
+// backup was uploaded, usually because it is already restarting with the restored configuration
+var ErrRestoreInProgress = errors.New("connection lost after the upload, restore probably in progress")
+
+// ValidateBackup checks that data has the format of a controller backup: a complete tar.gz archive
+// or an encrypted archive. Anything else is rejected with ErrInvalidBackup, including empty or
+// truncated payloads and the HTML pages the controller serves when the session has expired.
+func ValidateBackup(data []byte) (BackupFormat, error) {
+	return validateBackup(bytes.NewReader(data))
+}
+
+// validateBackup reads a backup from r up to EOF and checks it like ValidateBackup, so the backup
+// can be validated while it is streamed elsewhere
+func validateBackup(r io.Reader) (BackupFormat, error) {
+	src := &countingReader{r: io.LimitReader(r, MaxBackupSize+1)}
+
+	format, err := checkBackup(src)
+	if err == nil {
+		// Read what the format check left, e.g. the padding after the end of the tar archive
+		_, err = io.Copy(io.Discard, src)
+	}
+
+	if src.n > MaxBackupSize {
+		return "", fmt.Errorf("%w: larger than %d bytes", ErrInvalidBackup, MaxBackupSize)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// The salt and at least one cipher block must follow the header
+	if format == BackupFormatEncrypted {
+		size := src.n - int64(len(encryptedBackupHeader)) - 8
+		if size < 16 || size%16 != 0 {
+			return "", fmt.Errorf("%w: truncated encrypted archive", ErrInvalidBackup)
+		}
+	}
+
+	return format, nil
+}
+
+// checkBackupHead rejects payloads whose first MinBackupSize bytes are not a backup archive,
+// so the pages served when the session has expired are detected before anything is written
+func checkBackupHead(head []byte) error {
+	if len(head) < MinBackupSize {
+		return fmt.Errorf("%w: %d bytes is too small", ErrInvalidBackup, len(head))
+	}
+
+	lower := bytes.ToLower(bytes.TrimSpace(head[:MinBackupSize]))
+	for _, marker := range [][]byte{[]byte("<!doctype"), []byte("<html"), []byte("<?xml"), []byte("<ajax-response")} {
+		if bytes.HasPrefix(lower, marker) {
+			return fmt.Errorf("%w: received a web page instead of a backup archive", ErrInvalidBackup)
+		}
+	}
+
+	if !bytes.HasPrefix(head, encryptedBackupHeader) && (head[0] != 0x1f || head[1] != 0x8b) {
+		return fmt.Errorf("%w: neither a tar.gz nor an encrypted backup archive", ErrInvalidBackup)
+	}
+
+	return nil
+}
+
+// checkBackup reads the backup format from r. Encrypted archives are only identified by their
+// header, tar.gz archives are read up to the end of the tar archive.
+func checkBackup(r io.Reader) (BackupFormat, error) {
+	head, err := readHead(r)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkBackupHead(head); err != nil {
+		return "", err
+	}
+
+	if bytes.HasPrefix(head, encryptedBackupHeader) {
+		return BackupFormatEncrypted, nil
+	}
+
+	gz, err := gzip.NewReader(io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+
+	tr := tar.NewReader(gz)
+	files := 0
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: corrupted archive: %v", ErrInvalidBackup, err)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return "", fmt.Errorf("%w: corrupted archive: %v", ErrInvalidBackup, err)
+		}
+		files++
+	}
+
+	if files == 0 {
+		return "", fmt.Errorf("%w: empty archive", ErrInvalidBackup)
+	}
+
+	return BackupFormatTarGzip, nil
+}
+
+// readHead reads the first MinBackupSize bytes of r, or all of them when r is shorter
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, MinBackupSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("error reading backup: %v", err)
+	}
+	return head[:n], nil
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// errorReader records the first error other than io.EOF returned by r
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errorReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// errorWriter records the first error returned by w and the number of bytes written
+type errorWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (e *errorWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	e.n += int64(n)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// Backup downloads the controller configuration to outputFile. The file is only replaced
+// once the download completed.
+func (rc *Client) Backup(ctx context.Context, outputFile string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(outputFile), ".backup-*")
+	if err != nil {
+		return fmt.Errorf("error creating output file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := rc.BackupTo(ctx, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing output file: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), outputFile); err != nil {
+		return fmt.Errorf("error saving output file: %v", err)
+	}
+
+	return nil
+}
+
+// BackupTo downloads the controller configuration backup and writes it to w, returning the number
+// of bytes written. The backup is streamed to w while it is validated like ValidateBackup: payloads
+// that do not start like a backup archive, e.g. the login page of an expired session, are rejected
+// before anything is written, while a truncated or corrupted archive is only detected at the end of
+// the download. Callers must then discard what was written to w, as Backup does.
+func (rc *Client) BackupTo(ctx context.Context, w io.Writer) (int64, error) {
+	if err := rc.ensureLogin(ctx); err != nil {
+		return 0, err
+	}
+
+	// Define the URL for saving the backup
+	saveBackupURL := rc.server + backupPath
+
+	var resp *http.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		// Send the GET request to the save backup URL
+		var err error
+		resp, err = rc.send(ctx, true, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "GET", saveBackupURL, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			// Set the necessary headers
+			req.Header.Set("Accept", "application/octet-stream") // Specify the desired content type
+			return req, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if !isLoginRedirect(resp) || attempt > 0 {
+			break
+		}
+
+		resp.Body.Close()
+		if err := rc.relogin(ctx); err != nil {
+			return 0, fmt.Errorf("error renewing session: %v", err)
+		}
+	}
+	defer resp.Body.Close()
+
+	// Check if the response status code indicates success (e.g., 200 OK)
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("save backup failed with status code: %v", resp.StatusCode)
+	}
+
+	body := &errorReader{r: resp.Body}
+
+	head, err := readHead(body)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := checkBackupHead(head); err != nil {
+		return 0, err
+	}
+
+	// Write the backup while validating it, the error is only known at EOF
+	out := &errorWriter{w: w}
+	_, err = validateBackup(io.TeeReader(io.MultiReader(bytes.NewReader(head), body), out))
+	switch {
+	case out.err != nil:
+		return out.n, fmt.Errorf("error copying backup: %v", out.err)
+	case body.err != nil:
+		return out.n, fmt.Errorf("error reading backup: %v", body.err)
+	case err != nil:
+		return out.n, err
+	}
+
+	return out.n, nil
+}
+
+// Restore uploads a backup to the controller, which replaces its configuration and restarts.
+// The backup is validated before it is sent.
+func (rc *Client) Restore(ctx context.Context, backup io.Reader) error {
+	data, err := io.ReadAll(backup)
+	if err != nil {
+		return fmt.Errorf("error reading backup: %v", err)
+	}
+
+	if _, err := ValidateBackup(data); err != nil {
+		return err
+	}
+
+	if err := rc.ensureLogin(ctx); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("u", "backup.bak")
+	if err != nil {
+		return fmt.Errorf("error creating restore form: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("error creating restore form: %v", err)
+	}
+	if err := form.WriteField("action", "uploadFile"); err != nil {
+		return fmt.Errorf("error creating restore form: %v", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("error creating restore form: %v", err)
+	}
+
+	var resp *http.Response
+	for attempt := 0; attempt < 2; attempt++ {
+		// A restore restarts the controller, so a failed upload is never retried: the controller
+		// may already be applying it and a second upload would hit it mid-restore
+		resp, err = rc.send(ctx, false, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, "POST", rc.server+restorePath, bytes.NewReader(body.Bytes()))
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("X-CSRF-Token", rc.csrfToken)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			return req, nil
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %v", ErrRestoreInProgress, err)
+		}
+
+		if !isLoginRedirect(resp) || attempt > 0 {
+			break
+		}
+
+		// The login page is served before the upload is processed, so sending it again is safe
+		resp.Body.Close()
+		if err := rc.relogin(ctx); err != nil {
+			return fmt.Errorf("error renewing session: %v", err)
+		}
+	}
+	defer resp.Body.Close()
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: error reading response body: %v", ErrRestoreInProgress, err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status code %v", ErrRestoreInProgress, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("restore backup failed with status code: %v", resp.StatusCode)
+	}
+
+	result, err := ajax.ParseResponse(respData)
+	if err != nil {
+		return fmt.Errorf("restore backup failed: unexpected response: %v", err)
+	}
+
+	if code, message, failed := result.Failure(); failed {
+		return &ControllerError{Kind: classifyError(code), Code: code, Message: message}
+	}
+
+	return nil
+}
@@ -1,25 +1,50 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
 )
 
+// DefaultTimeout bounds every HTTP request sent to the controller
+const DefaultTimeout = 30 * time.Second
+
 type Client struct {
-	Debug     bool
-	client    *http.Client
-	server    string // Add a field to store the server address
-	csrfToken string // Add a field to store the CSRF token
-	cookie    string // Add a field to store the cookie
+	Debug        bool
+	SessionStore SessionStore // Optional, persists sessions across invocations
+	Retry        RetryPolicy  // Applied to idempotent requests only
+	RateLimiter  *RateLimiter // Optional, shared by every request
+	client       *http.Client
+	server       string   // Add a field to store the server address
+	serverURL    *url.URL // Parsed server address, used to look up cookies
+	username     string   // Kept to log in again when the session expires
+	password     string   // Kept to log in again when the session expires
+	csrfToken    string   // Add a field to store the CSRF token
 }
 
 func New(server string, caCertPath string) (*Client, error) {
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server address %q: %v", server, err)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cookie jar: %v", err)
+	}
+
 	// Base transport
 	tr := &http.Transport{}
 
@@ -39,16 +64,68 @@ func New(server string, caCertPath string) (*Client, error) {
 	}
 
 	httpClient := &http.Client{
+		Timeout:   DefaultTimeout,
 		Transport: tr,
+		Jar:       jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
 	}
 
-	return &Client{client: httpClient, server: server, Debug: false}, nil
+	return &Client{client: httpClient, server: server, serverURL: serverURL, Retry: DefaultRetryPolicy, Debug: false}, nil
+}
+
+// Server returns the controller address the client talks to
+func (rc *Client) Server() string {
+	return rc.server
 }
 
-func (rc *Client) Login(username, password string) error {
+// SetTimeout changes the time limit for each HTTP request, zero disables it
+func (rc *Client) SetTimeout(timeout time.Duration) {
+	rc.client.Timeout = timeout
+}
+
+// SetCredentials stores the credentials used to log in on the first request that needs a session,
+// so commands working offline never contact the controller
+func (rc *Client) SetCredentials(username, password string) {
+	rc.username = username
+	rc.password = password
+}
+
+// Login authenticates against the controller, reusing a stored session when a
+// SessionStore is configured. Expired sessions are renewed transparently.
+func (rc *Client) Login(ctx context.Context, username, password string) error {
+	rc.SetCredentials(username, password)
+
+	if rc.SessionStore != nil {
+		session, err := rc.SessionStore.Load(rc.server, username)
+		if err != nil {
+			if rc.Debug {
+				fmt.Printf("Ignoring stored session: %v\n", err)
+			}
+		} else if session != nil {
+			rc.restoreSession(session)
+			return nil
+		}
+	}
+
+	return rc.login(ctx)
+}
+
+// ensureLogin logs in with the stored credentials unless a session is already established
+func (rc *Client) ensureLogin(ctx context.Context) error {
+	if rc.csrfToken != "" {
+		return nil
+	}
+	return rc.Login(ctx, rc.username, rc.password)
+}
+
+func (rc *Client) login(ctx context.Context) error {
+	username, password := rc.username, rc.password
+	if password == "" {
+		return fmt.Errorf("password is required")
+	}
+
 	// Login URL
 	loginURL := rc.server + "/admin/login.jsp"
 	loginData := url.Values{
@@ -57,10 +134,17 @@ func (rc *Client) Login(username, password string) error {
 		"ok":       {"Log In"},
 	}
 
-	// Send the login request
-	loginResp, err := rc.client.PostForm(loginURL, loginData)
+	// Send the login request, logging in again is harmless so it can be retried
+	loginResp, err := rc.send(ctx, true, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", loginURL, strings.NewReader(loginData.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
 	if err != nil {
-		return fmt.Errorf("error sending login request: %v", err)
+		return fmt.Errorf("login request failed: %v", err)
 	}
 	defer loginResp.Body.Close()
 
@@ -76,70 +160,156 @@ func (rc *Client) Login(username, password string) error {
 		return fmt.Errorf("check user and password, status code: %v", loginResp.StatusCode)
 	}
 
-	rc.cookie = loginResp.Header.Get("Set-Cookie")
 	rc.csrfToken = loginResp.Header.Get("HTTP_X_CSRF_TOKEN")
 
+	if rc.SessionStore != nil {
+		session := &Session{
+			Server:    rc.server,
+			Username:  username,
+			CSRFToken: rc.csrfToken,
+			Cookies:   cookiesToSession(rc.client.Jar.Cookies(rc.serverURL)),
+			Created:   time.Now(),
+		}
+		if err := rc.SessionStore.Save(session); err != nil && rc.Debug {
+			fmt.Printf("Unable to store session: %v\n", err)
+		}
+	}
+
 	return nil
 }
 
-func (rc *Client) getCurrentTimestamp() string {
-	// Get the current time in UnixNano format (nanoseconds since epoch)
-	currentTime := time.Now().UnixNano()
+// relogin discards the current session and authenticates again with the stored credentials
+func (rc *Client) relogin(ctx context.Context) error {
+	if rc.Debug {
+		fmt.Println("Session expired, logging in again")
+	}
+
+	if rc.password == "" {
+		return fmt.Errorf("session expired and no credentials available to log in again")
+	}
+
+	if rc.SessionStore != nil {
+		if err := rc.SessionStore.Delete(rc.server, rc.username); err != nil && rc.Debug {
+			fmt.Printf("Unable to delete stored session: %v\n", err)
+		}
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fmt.Errorf("error creating cookie jar: %v", err)
+	}
+	rc.client.Jar = jar
+	rc.csrfToken = ""
+
+	return rc.login(ctx)
+}
+
+func (rc *Client) restoreSession(session *Session) {
+	rc.client.Jar.SetCookies(rc.serverURL, cookiesFromSession(session.Cookies))
+	rc.csrfToken = session.CSRFToken
+}
 
-	// Convert nanoseconds to milliseconds (1 nanosecond = 1e-6 milliseconds)
-	milliseconds := currentTime / int64(time.Millisecond)
+// isLoginRedirect reports whether the controller is sending us back to the login page
+func isLoginRedirect(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusFound && resp.StatusCode != http.StatusMovedPermanently {
+		return false
+	}
+	return strings.Contains(resp.Header.Get("Location"), "login.jsp")
+}
 
-	// Convert nanoseconds to microseconds (1 nanosecond = 1e-3 microseconds)
-	microseconds := currentTime / int64(time.Microsecond)
+// Do performs a single ajax round-trip and returns the parsed response. Failures reported by the
+// controller inside the response body are returned as *ControllerError.
+// If the session has expired it is renewed, and idempotent requests are sent again once. Other
+// requests return the ErrSessionExpired error so the caller decides whether to send them again.
+func (rc *Client) Do(ctx context.Context, req *ajax.Request) (*ajax.Response, error) {
+	body, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
 
-	// Calculate the fractional part (microseconds) by subtracting milliseconds
-	fractionalPart := microseconds - (milliseconds * 1000)
+	if err := rc.ensureLogin(ctx); err != nil {
+		return nil, err
+	}
 
-	// Format the timestamp in the desired format
-	// Use %d for milliseconds and %04d for microseconds with leading zeros
-	timestamp := fmt.Sprintf("%d.%04d", milliseconds, fractionalPart)
+	idempotent := req.Action.Idempotent()
 
-	return timestamp
+	resp, err := rc.roundTrip(ctx, req.Path(), body, idempotent)
+	if errors.Is(err, ErrSessionExpired) {
+		if err := rc.relogin(ctx); err != nil {
+			return nil, fmt.Errorf("error renewing session: %v", err)
+		}
+		if !idempotent {
+			return nil, fmt.Errorf("%w, logged in again but %s requests are not sent twice", err, req.Action)
+		}
+		resp, err = rc.roundTrip(ctx, req.Path(), body, idempotent)
+	}
+
+	return resp, err
 }
 
-func (rc *Client) Backup(outputFile string) error {
-	// Define the URL for saving the backup
-	saveBackupURL := rc.server + "/admin/webPage/system/admin/_savebackup.jsp"
+func (rc *Client) roundTrip(ctx context.Context, path string, body []byte, idempotent bool) (*ajax.Response, error) {
+	data, err := rc.post(ctx, path, body, idempotent)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create an HTTP GET request to the save backup URL
-	req, err := http.NewRequest("GET", saveBackupURL, nil)
+	resp, err := ajax.ParseResponse(data)
 	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+		return nil, err
 	}
 
-	// Set the necessary headers
-	req.Header.Set("Accept", "application/octet-stream") // Specify the desired content type
-	req.Header.Set("Cookie", rc.cookie)                  // Use the stored cookie
+	if code, message, failed := resp.Failure(); failed {
+		return resp, &ControllerError{
+			Kind:    classifyError(code),
+			Code:    code,
+			Message: message,
+		}
+	}
+
+	return resp, nil
+}
+
+func (rc *Client) post(ctx context.Context, path string, body []byte, idempotent bool) ([]byte, error) {
+	if rc.Debug {
+		fmt.Println(string(body))
+	}
+
+	// Send the request
+	resp, err := rc.send(ctx, idempotent, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", rc.server+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
 
-	// Send the GET request
-	resp, err := rc.client.Do(req)
+		// Set the request headers
+		req.Header.Set("X-CSRF-Token", rc.csrfToken)
+		req.Header.Set("Content-Type", "text/xml")
+		return req, nil
+	})
 	if err != nil {
-		return fmt.Errorf("error sending request: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Check if the response status code indicates success (e.g., 200 OK)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("save backup failed with status code: %v", resp.StatusCode)
+	if isLoginRedirect(resp) {
+		return nil, &ControllerError{Kind: ErrSessionExpired, Message: "redirected to the login page"}
 	}
 
-	// Create or open the outputFile for writing
-	outFile, err := os.Create(outputFile)
+	// Read the response body
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error creating output file: %v", err)
+		return nil, fmt.Errorf("error reading response body: %v", err)
 	}
-	defer outFile.Close()
 
-	// Copy the response body (binary file) to the outputFile
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		return fmt.Errorf("error copying response body to output file: %v", err)
+	// Check if the response status code indicates success (e.g., 200 OK)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed with status code: %v", resp.StatusCode)
 	}
 
-	return nil
+	// An expired session is answered with the HTML login page instead of an ajax-response
+	if !bytes.Contains(data, []byte("<ajax-response")) {
+		return nil, &ControllerError{Kind: ErrSessionExpired, Message: "received the login page instead of an ajax-response"}
+	}
+
+	return data, nil
 }
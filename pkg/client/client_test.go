@@ -0,0 +1,292 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/fake"
+)
+
+// newClient starts a fake controller and returns a client logged in to it
+func newClient(t *testing.T) (*client.Client, *fake.Controller) {
+	t.Helper()
+
+	controller := fake.New("admin", "secret")
+	server := fake.NewServer(controller)
+	t.Cleanup(server.Close)
+
+	rc, err := client.New(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Retry = client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	if err := rc.Login(context.Background(), "admin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	return rc, controller
+}
+
+func TestDpskRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+
+	created, err := rc.Dpsk().Create(ctx, client.CreateOptions{WlansvcID: 1, User: "guest", Count: 3, DpskLen: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 3 {
+		t.Fatalf("Create() returned %d entries, want 3", len(created))
+	}
+	for _, entry := range created {
+		if entry.WlansvcID != 1 || len(entry.Passphrase) != 12 {
+			t.Errorf("created entry %+v, want WLAN 1 and a 12 character passphrase", entry)
+		}
+	}
+
+	var id int
+	for id = range created {
+		break
+	}
+
+	if err := rc.Dpsk().Modify(ctx, id, map[string]string{"user": "renamed", "dvlan-id": "20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := rc.Dpsk().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("List() returned %d entries, want 3", len(entries))
+	}
+	if entry := entries[id]; entry.User != "renamed" || entry.DvlanID != 20 {
+		t.Errorf("modified entry %+v, want user renamed and dvlan-id 20", entry)
+	}
+
+	if err := rc.Dpsk().Modify(ctx, 999, map[string]string{"user": "missing"}); !errors.Is(err, client.ErrInvalidAttribute) {
+		t.Errorf("Modify() of a missing entry = %v, want ErrInvalidAttribute", err)
+	}
+
+	if err := rc.Dpsk().Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := controller.Dpsks()[id]; ok || len(controller.Dpsks()) != 2 {
+		t.Errorf("entry %d still stored after Delete()", id)
+	}
+}
+
+func TestCreateUserNames(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	controller.AddDpsk(map[string]string{"user": "guest_9", "wlansvc-id": "1"})
+	controller.AddDpsk(map[string]string{"user": "single", "wlansvc-id": "1"})
+
+	created, err := rc.Dpsk().Create(ctx, client.CreateOptions{WlansvcID: 1, User: "guest", Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []string
+	for _, entry := range created {
+		users = append(users, entry.User)
+	}
+	sort.Strings(users)
+	if strings.Join(users, ",") != "guest_1,guest_2" {
+		t.Errorf("Create() returned %v, want guest_1 and guest_2", users)
+	}
+
+	created, err = rc.Dpsk().Create(ctx, client.CreateOptions{WlansvcID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, entry := range created {
+		if entry.User != "BatchDPSK_User_"+strconv.Itoa(id) || len(created) != 1 {
+			t.Errorf("Create() without user returned %v", created)
+		}
+	}
+
+	for _, opts := range []client.CreateOptions{
+		{WlansvcID: 1, User: "single"},
+		{WlansvcID: 1, User: "guest", Count: 3},
+	} {
+		before := len(controller.Dpsks())
+		if _, err := rc.Dpsk().Create(ctx, opts); !errors.Is(err, client.ErrUserExists) {
+			t.Errorf("Create(%+v) = %v, want ErrUserExists", opts, err)
+		}
+		if after := len(controller.Dpsks()); after != before {
+			t.Errorf("Create(%+v) added %d entries, want none", opts, after-before)
+		}
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	id := controller.AddDpsk(map[string]string{"user": "guest"})
+
+	// Reads are retried
+	controller.InjectFailures(http.StatusServiceUnavailable, http.StatusBadGateway)
+	entries, err := rc.Dpsk().List(ctx)
+	if err != nil {
+		t.Fatalf("List() after two failures: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("List() returned %d entries, want 1", len(entries))
+	}
+
+	// Deletes are not, a retry after a lost response would fail on the deleted entry
+	controller.InjectFailures(http.StatusServiceUnavailable)
+	if err := rc.Dpsk().Delete(ctx, id); err == nil {
+		t.Error("Delete() succeeded after a failure, want no retry")
+	}
+	if _, ok := controller.Dpsks()[id]; !ok {
+		t.Errorf("entry %d deleted by a failed request", id)
+	}
+}
+
+func TestSessionRenewal(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	controller.AddDpsk(map[string]string{"user": "guest"})
+
+	controller.ExpireSessions()
+
+	entries, err := rc.Dpsk().List(ctx)
+	if err != nil {
+		t.Fatalf("List() with an expired session: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("List() returned %d entries, want 1", len(entries))
+	}
+	if logins := controller.Logins(); logins != 2 {
+		t.Errorf("controller served %d logins, want 2", logins)
+	}
+}
+
+func TestControllerErrors(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	id := controller.AddDpsk(map[string]string{"user": "guest"})
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"short passphrase", createErr(rc, client.CreateOptions{WlansvcID: 1, DpskLen: 5}), client.ErrInvalidAttribute},
+		{"unknown WLAN", createErr(rc, client.CreateOptions{WlansvcID: 9}), client.ErrInvalidAttribute},
+		{"too many entries", createErr(rc, client.CreateOptions{WlansvcID: 1, Count: fake.MaxDpsk}), client.ErrLimitReached},
+		{"read-only attribute", rc.Dpsk().Modify(ctx, id, map[string]string{"usage": "3"}), client.ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+}
+
+func createErr(rc *client.Client, opts client.CreateOptions) error {
+	_, err := rc.Dpsk().Create(context.Background(), opts)
+	return err
+}
+
+func TestSessionExpiredDoCmd(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	req := func() *ajax.Request {
+		return ajax.DoCmd("system", ajax.XCmd("batch-dpsk", ajax.Attr("type", "gen"), ajax.Attr("wlansvc-id", "1"), ajax.Attr("user", "guest")))
+	}
+
+	// The command is not sent again after logging in, it could be applied twice
+	controller.ExpireSessions()
+	if _, err := rc.Do(ctx, req()); !errors.Is(err, client.ErrSessionExpired) {
+		t.Fatalf("Do() with an expired session = %v, want ErrSessionExpired", err)
+	}
+	if n := len(controller.Dpsks()); n != 0 {
+		t.Fatalf("controller holds %d entries, want none", n)
+	}
+
+	// The renewed session is used by the next request
+	if _, err := rc.Do(ctx, req()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(controller.Dpsks()); n != 1 {
+		t.Errorf("controller holds %d entries, want 1", n)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	rc, controller := newClient(t)
+	controller.AddDpsk(map[string]string{"user": "kept"})
+
+	var buf bytes.Buffer
+	n, err := rc.BackupTo(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("BackupTo() = %d, wrote %d bytes", n, buf.Len())
+	}
+	if format, err := client.ValidateBackup(buf.Bytes()); err != nil || format != client.BackupFormatTarGzip {
+		t.Fatalf("ValidateBackup() = %v, %v", format, err)
+	}
+
+	controller.AddDpsk(map[string]string{"user": "dropped"})
+
+	if err := rc.Restore(ctx, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	dpsks := controller.Dpsks()
+	if len(dpsks) != 1 || dpsks[1]["user"] != "kept" {
+		t.Errorf("entries after Restore() = %v, want only kept", dpsks)
+	}
+}
+
+func TestValidateBackup(t *testing.T) {
+	rc, _ := newClient(t)
+
+	var buf bytes.Buffer
+	if _, err := rc.BackupTo(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	encrypted := append([]byte("Salted__12345678"), bytes.Repeat([]byte{0xaa}, 256)...)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		valid bool
+	}{
+		{"tar.gz", archive, true},
+		{"encrypted", encrypted, true},
+		{"truncated tar.gz", archive[:len(archive)-20], false},
+		{"truncated encrypted", encrypted[:len(encrypted)-3], false},
+		{"login page", []byte("\n<!DOCTYPE html>" + string(bytes.Repeat([]byte(" "), 200))), false},
+		{"unknown format", bytes.Repeat([]byte{0x42}, 256), false},
+		{"too small", archive[:64], false},
+		{"empty", nil, false},
+	}
+
+	for _, tt := range tests {
+		_, err := client.ValidateBackup(tt.data)
+		if tt.valid && err != nil {
+			t.Errorf("%s: ValidateBackup() = %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, client.ErrInvalidBackup) {
+			t.Errorf("%s: ValidateBackup() = %v, want ErrInvalidBackup", tt.name, err)
+		}
+	}
+}
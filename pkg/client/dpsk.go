@@ -1,11 +1,15 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
 	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/dpsk"
 )
 
@@ -17,43 +21,15 @@ func (rc *Client) Dpsk() *DpskService {
 	return &DpskService{Client: rc}
 }
 
-func (d *DpskService) List() (dpsk.Entries, error) {
-	// Create the request URL
-	url := d.Client.server + "/admin/_cmdstat.jsp"
+func (d *DpskService) List(ctx context.Context) (dpsk.Entries, error) {
+	req := ajax.GetStat("stamgr", "dpsk-list", ajax.NewElement("dpsklist"))
 
-	body := fmt.Sprintf(`<ajax-request action="getstat" comp="stamgr" updater="dpsk-list.%s">
-		<dpsklist/>
-	</ajax-request>`, d.Client.getCurrentTimestamp())
-
-	if d.Client.Debug {
-		fmt.Println(body)
-	}
-
-	// Create the request object
-	req, err := http.NewRequest("POST", url, strings.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
-	}
-
-	// Set the request headers
-	req.Header.Set("X-CSRF-Token", d.Client.csrfToken)
-	req.Header.Set("Cookie", d.Client.cookie)
-	req.Header.Set("Content-Type", "text/xml")
-
-	// Send the request
-	resp, err := d.Client.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Read the response body
-	xmlData, err := io.ReadAll(resp.Body)
+	resp, err := d.Client.Do(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+		return nil, err
 	}
 
-	entries, err := dpsk.FromXml(xmlData)
+	entries, err := dpsk.FromXml(resp.Raw)
 	if d.Client.Debug {
 		fmt.Printf("Parsed DPSKs:\n")
 		for _, dpsk := range entries {
@@ -64,99 +40,167 @@ func (d *DpskService) List() (dpsk.Entries, error) {
 	return entries, err
 }
 
-func (d *DpskService) Create(wlansvcID int, user string, dpskLen int) error {
-	// Create the request URL
-	url := d.Client.server + "/admin/_cmdstat.jsp"
-
-	// Create the request body
-	body := fmt.Sprintf(`<ajax-request action='docmd' checkAbility='2' updater='system.%s' comp='system'>
-		<xcmd
-			cmd='batch-dpsk'
-			type='gen'
-			num='1'
-			max-num='2048'
-			batch-dpsk=''
-			wlansvc-id='%d'
-			role-id=''
-			dpsk-len='%d'
-			dvlan-id=''
-			user='%s'
-		/>
-	</ajax-request>`, d.Client.getCurrentTimestamp(), wlansvcID, dpskLen, user)
+// CreateOptions describes the entries generated by a batch-dpsk command
+type CreateOptions struct {
+	WlansvcID int       // WLAN service the entries belong to
+	User      string    // Username, the controller adds a numeric suffix when Count is above 1
+	DpskLen   int       // Passphrase length, defaults to 8
+	RoleID    string    // Optional role assigned to the entries
+	DvlanID   int       // Optional dynamic VLAN, zero disables it
+	Expire    time.Time // Optional expiration, zero never expires
+	Count     int       // Number of entries to generate, defaults to 1
+}
 
-	if d.Client.Debug {
-		fmt.Println(body)
+// MaxDpskEntries is the maximum number of DPSK entries a controller holds
+const MaxDpskEntries = 2048
+
+// ErrCreatedNotFound is returned when the controller accepted a create request but the new
+// entries are missing from the DPSK list afterwards
+var ErrCreatedNotFound = errors.New("created DPSK not found")
+
+// ErrUserExists is returned by Create when a user name it would generate already exists on the WLAN
+var ErrUserExists = errors.New("DPSK already exists")
+
+// Create generates the entries described by opts and returns them. The new entries are found by
+// diffing the DPSK list before and after the request, keeping those on the WLAN with exactly the
+// user names the controller generates: User, User_1 to User_<Count> when Count is above 1, or
+// BatchDPSK_User_<id> without User.
+func (d *DpskService) Create(ctx context.Context, opts CreateOptions) (dpsk.Entries, error) {
+	if opts.DpskLen == 0 {
+		opts.DpskLen = 8
+	}
+	if opts.Count == 0 {
+		opts.Count = 1
 	}
 
-	// Create the request object
-	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	dvlanID := ""
+	if opts.DvlanID > 0 {
+		dvlanID = strconv.Itoa(opts.DvlanID)
+	}
+
+	xcmd := ajax.XCmd("batch-dpsk",
+		ajax.Attr("type", "gen"),
+		ajax.Attr("num", strconv.Itoa(opts.Count)),
+		ajax.Attr("max-num", strconv.Itoa(MaxDpskEntries)),
+		ajax.Attr("batch-dpsk", ""),
+		ajax.Attr("wlansvc-id", strconv.Itoa(opts.WlansvcID)),
+		ajax.Attr("role-id", opts.RoleID),
+		ajax.Attr("dpsk-len", strconv.Itoa(opts.DpskLen)),
+		ajax.Attr("dvlan-id", dvlanID),
+		ajax.Attr("user", opts.User),
+	)
+	if !opts.Expire.IsZero() {
+		xcmd.Set("expire", strconv.FormatInt(opts.Expire.Unix(), 10))
+	}
+
+	req := ajax.DoCmd("system", xcmd)
+
+	before, err := d.List(ctx)
 	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error getting DPSK list before creation: %w", err)
+	}
+
+	// Existing entries with a generated name could not be told apart from the new ones,
+	// entries named after their id never clash since new entries get new ids
+	for id, entry := range before {
+		if opts.User != "" && entry.WlansvcID == opts.WlansvcID && createdUser(id, entry.User, opts) {
+			return nil, fmt.Errorf("%w for username: %s and wlanID: %d", ErrUserExists, entry.User, opts.WlansvcID)
+		}
 	}
 
-	// Set the request headers
-	req.Header.Set("X-CSRF-Token", d.Client.csrfToken)
-	req.Header.Set("Cookie", d.Client.cookie)
-	req.Header.Set("Content-Type", "text/xml")
+	if _, err := d.Client.Do(ctx, req); err != nil {
+		return nil, fmt.Errorf("create DPSK user failed: %w", err)
+	}
 
-	// Send the request
-	resp, err := d.Client.client.Do(req)
+	after, err := d.List(ctx)
 	if err != nil {
-		return fmt.Errorf("error sending request: %v", err)
+		return nil, fmt.Errorf("error getting DPSK list after creation: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Check if the response status code indicates success (e.g., 200 OK)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("create DPSK user failed with status code: %v", resp.StatusCode)
+	created := make(dpsk.Entries)
+	for id, entry := range after {
+		if _, ok := before[id]; ok {
+			continue
+		}
+		if entry.WlansvcID != opts.WlansvcID || !createdUser(id, entry.User, opts) {
+			continue
+		}
+		created[id] = entry
 	}
 
-	return nil
-}
+	if len(created) == 0 {
+		return nil, fmt.Errorf("%w for username: %s and wlanID: %d", ErrCreatedNotFound, opts.User, opts.WlansvcID)
+	}
 
-func (d *DpskService) Modify(dpskID int, fields map[string]string) error {
-	// Create the request URL
-	url := d.Client.server + "/admin/_conf.jsp"
+	if len(created) < opts.Count {
+		return created, fmt.Errorf("%w: only %d of %d entries found for username: %s and wlanID: %d", ErrCreatedNotFound, len(created), opts.Count, opts.User, opts.WlansvcID)
+	}
 
-	// Create the request body
-	body := fmt.Sprintf(`<ajax-request action='updobj' updater='dpsk-list.%s'comp='dpsk-list'>
-		<dpsk id='%d' name='dpsk%d' IS_PARTIAL='true' %s />
-	</ajax-request>`, d.Client.getCurrentTimestamp(), dpskID, dpskID, fieldsToString(fields))
+	return created, nil
+}
 
-	if d.Client.Debug {
-		fmt.Println(body)
+// createdUser reports whether user is the name the controller assigns to entry id when generating opts
+func createdUser(id int, user string, opts CreateOptions) bool {
+	switch {
+	case opts.User == "":
+		return user == "BatchDPSK_User_"+strconv.Itoa(id)
+	case opts.Count == 1:
+		return user == opts.User
 	}
 
-	// Create the request object
-	req, err := http.NewRequest("POST", url, strings.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+	suffix, ok := strings.CutPrefix(user, opts.User+"_")
+	if !ok {
+		return false
 	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n >= 1 && n <= opts.Count && strconv.Itoa(n) == suffix
+}
 
-	// Set the request headers
-	req.Header.Set("X-CSRF-Token", d.Client.csrfToken)
-	req.Header.Set("Cookie", d.Client.cookie)
-	req.Header.Set("Content-Type", "text/xml")
+func (d *DpskService) Modify(ctx context.Context, dpskID int, fields map[string]string) error {
+	id := strconv.Itoa(dpskID)
+	obj := ajax.NewElement("dpsk",
+		ajax.Attr("id", id),
+		ajax.Attr("name", "dpsk"+id),
+		ajax.Attr("IS_PARTIAL", "true"),
+	)
+
+	// Sort the fields so the request is deterministic
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	// Send the request
-	resp, err := d.Client.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request: %v", err)
+	for _, key := range keys {
+		obj.Set(key, fields[key])
 	}
-	defer resp.Body.Close()
 
-	// Check if the response status code indicates success (e.g., 200 OK)
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("create DPSK user failed with status code: %v", resp.StatusCode)
+	req := ajax.UpdObj("dpsk-list", obj)
+
+	if _, err := d.Client.Do(ctx, req); err != nil {
+		return fmt.Errorf("modify DPSK failed: %w", err)
 	}
 
 	return nil
 }
 
-func fieldsToString(m map[string]string) string {
-	pairs := make([]string, 0, len(m))
-	for key, value := range m {
-		pairs = append(pairs, fmt.Sprintf("%s='%s'", key, value))
+// Delete removes the DPSK entries with the given ids in a single delobj request
+func (d *DpskService) Delete(ctx context.Context, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	objs := make([]ajax.Element, 0, len(ids))
+	for _, dpskID := range ids {
+		id := strconv.Itoa(dpskID)
+		objs = append(objs, ajax.NewElement("dpsk", ajax.Attr("id", id), ajax.Attr("name", "dpsk"+id)))
 	}
-	return strings.Join(pairs, " ")
+
+	req := ajax.DelObj("dpsk-list", objs...)
+
+	if _, err := d.Client.Do(ctx, req); err != nil {
+		return fmt.Errorf("delete DPSK failed: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for failures reported by the controller. Match them with errors.Is,
+// or use errors.As with *ControllerError to access the controller message.
+var (
+	ErrSessionExpired   = errors.New("controller session expired")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrLimitReached     = errors.New("limit reached")
+	ErrInvalidAttribute = errors.New("invalid attribute")
+	ErrRejected         = errors.New("request rejected by controller")
+)
+
+// ControllerError is returned when the controller answers a request with an error in the ajax-response body
+type ControllerError struct {
+	Kind    error  // One of the Err* sentinel errors
+	Code    string // Controller error code, if any
+	Message string // Controller error message
+}
+
+func (e *ControllerError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%v: %s (code %s)", e.Kind, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Message)
+}
+
+func (e *ControllerError) Unwrap() error {
+	return e.Kind
+}
+
+// errorCodes maps the code of a controller error element, or the status of a failed xmsg, to a
+// sentinel error. Messages are free text and never used: "login name already exists" is not an
+// expired session. Expired sessions are detected from the transport, see Client.post.
+var errorCodes = map[string]error{
+	"2": ErrInvalidAttribute, // Invalid attribute value
+	"3": ErrInvalidAttribute, // The referenced object does not exist
+	"4": ErrPermissionDenied, // Read-only attribute or missing privilege
+	"5": ErrLimitReached,     // Maximum number of objects reached
+}
+
+// classifyError maps a controller error code to one of the sentinel errors, ErrRejected when unknown
+func classifyError(code string) error {
+	if kind, ok := errorCodes[code]; ok {
+		return kind
+	}
+	return ErrRejected
+}
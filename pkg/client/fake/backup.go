@@ -0,0 +1,150 @@
+package fake
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+)
+
+// handleBackup serves the configuration as a gzipped tar archive with one XML document per
+// configuration list, laid out like the etc/airespider directory of a real backup
+func (c *Controller) handleBackup(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	files := c.backupFiles()
+	c.mu.Unlock()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+"unleashed_"+now.Format("20060102")+`.bak"`)
+	w.Write(buf.Bytes())
+}
+
+type backupFile struct {
+	name string
+	data []byte
+}
+
+// backupFiles renders the current state, the caller must hold c.mu
+func (c *Controller) backupFiles() []backupFile {
+	stat := c.dpskList()
+	list, _ := stat.Child("dpsk-list")
+
+	system := ajax.NewElement("system", ajax.Attr("version", Version), ajax.Attr("model", Model))
+
+	var files []backupFile
+	for _, f := range []struct {
+		name string
+		el   ajax.Element
+	}{
+		{"etc/airespider/system.xml", system},
+		{"etc/airespider/dpsk-list.xml", list},
+		{"etc/airespider/wlansvc-list.xml", c.wlanList()},
+		{"etc/airespider/role-list.xml", c.roleList()},
+	} {
+		data, _ := xml.Marshal(f.el)
+		files = append(files, backupFile{name: f.name, data: append([]byte(xml.Header), data...)})
+	}
+
+	return files
+}
+
+// handleRestore accepts a backup produced by handleBackup and replaces the DPSK entries with
+// the ones it contains. Other configuration lists are left untouched.
+func (c *Controller) handleRestore(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("u")
+	if err != nil {
+		writeResponse(w, "restore", xmsg(fmt.Errorf("invalid attribute: missing backup file")))
+		return
+	}
+	defer file.Close()
+
+	list, err := readBackupDpskList(file)
+	if err != nil {
+		writeResponse(w, "restore", xmsg(fmt.Errorf("invalid backup file: %v", err)))
+		return
+	}
+
+	c.mu.Lock()
+	c.dpsks = make(map[int]map[string]string)
+	c.nextDpskID = 1
+	for _, entry := range list.Children {
+		attrs := entry.AttrMap()
+		id, err := strconv.Atoi(attrs["id"])
+		if err != nil {
+			continue
+		}
+		c.dpsks[id] = attrs
+		if id >= c.nextDpskID {
+			c.nextDpskID = id + 1
+		}
+	}
+	c.mu.Unlock()
+
+	writeResponse(w, "restore", xmsg(nil))
+}
+
+func readBackupDpskList(r io.Reader) (ajax.Element, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return ajax.Element{}, err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return ajax.Element{}, fmt.Errorf("dpsk-list.xml not found")
+		}
+		if err != nil {
+			return ajax.Element{}, err
+		}
+
+		if hdr.Name != "etc/airespider/dpsk-list.xml" {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return ajax.Element{}, err
+		}
+
+		var list ajax.Element
+		if err := xml.Unmarshal(data, &list); err != nil {
+			return ajax.Element{}, err
+		}
+		return list, nil
+	}
+}
@@ -0,0 +1,252 @@
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+)
+
+// dpskAttrs lists the attributes of a DPSK entry in the order the controller emits them
+var dpskAttrs = []string{
+	"id", "role-id", "mac", "wlansvc-id", "dvlan-id", "user", "last-rekey", "next-rekey",
+	"expire", "start-point", "passphrase", "ip-addr", "cur-shared-num", "usage",
+}
+
+// readOnlyDpskAttrs can not be changed with updobj
+var readOnlyDpskAttrs = map[string]bool{
+	"id": true, "cur-shared-num": true, "usage": true, "last-rekey": true,
+}
+
+// AddDpsk stores an entry with the given attributes, filling in defaults for missing ones,
+// and returns its id. It bypasses validation so tests can seed arbitrary state.
+func (c *Controller) AddDpsk(attrs map[string]string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.addDpsk(attrs)
+}
+
+// Dpsks returns a copy of the stored entries keyed by id
+func (c *Controller) Dpsks() map[int]map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[int]map[string]string, len(c.dpsks))
+	for id, entry := range c.dpsks {
+		copied := make(map[string]string, len(entry))
+		for k, v := range entry {
+			copied[k] = v
+		}
+		result[id] = copied
+	}
+	return result
+}
+
+func (c *Controller) addDpsk(attrs map[string]string) int {
+	id := c.nextDpskID
+	c.nextDpskID++
+
+	entry := map[string]string{
+		"id":             strconv.Itoa(id),
+		"role-id":        "",
+		"mac":            "",
+		"wlansvc-id":     "1",
+		"dvlan-id":       "",
+		"user":           fmt.Sprintf("BatchDPSK_User_%d", id),
+		"last-rekey":     "0",
+		"next-rekey":     "0",
+		"expire":         "0",
+		"start-point":    "0",
+		"passphrase":     randomPassphrase(8),
+		"ip-addr":        "",
+		"cur-shared-num": "0",
+		"usage":          "",
+	}
+	for k, v := range attrs {
+		if k != "id" {
+			entry[k] = v
+		}
+	}
+
+	c.dpsks[id] = entry
+	return id
+}
+
+func (c *Controller) dpskList() ajax.Element {
+	ids := make([]int, 0, len(c.dpsks))
+	for id := range c.dpsks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	list := ajax.NewElement("dpsk-list")
+	for _, id := range ids {
+		entry := ajax.NewElement("dpsk")
+		for _, attr := range dpskAttrs {
+			entry.Set(attr, c.dpsks[id][attr])
+		}
+		list.Children = append(list.Children, entry)
+	}
+
+	return ajax.Element{Name: "apstamgr-stat", Children: []ajax.Element{list}}
+}
+
+// roleExists accepts an empty id, which means no role
+func (c *Controller) roleExists(id string) bool {
+	if id == "" {
+		return true
+	}
+	for _, r := range c.roles {
+		if strconv.Itoa(r.ID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) wlanExists(id string) bool {
+	for _, w := range c.wlans {
+		if strconv.Itoa(w.ID) == id && w.Dpsk {
+			return true
+		}
+	}
+	return false
+}
+
+// batchDpsk emulates the batch-dpsk xcmd, generating num entries in one go
+func (c *Controller) batchDpsk(attrs map[string]string) error {
+	if attrs["type"] != "gen" {
+		return fmt.Errorf("unsupported batch-dpsk type: %s", attrs["type"])
+	}
+
+	num, err := atoiDefault(attrs["num"], 1)
+	if err != nil || num < 1 {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute num: %s", attrs["num"])}
+	}
+
+	maxNum, err := atoiDefault(attrs["max-num"], MaxDpsk)
+	if err != nil || maxNum > MaxDpsk {
+		maxNum = MaxDpsk
+	}
+	if len(c.dpsks)+num > maxNum {
+		return &Error{Code: "5", Msg: fmt.Sprintf("exceeds the maximum number of DPSK entries (%d)", maxNum)}
+	}
+
+	if !c.wlanExists(attrs["wlansvc-id"]) {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute wlansvc-id: %s is not a DPSK enabled WLAN", attrs["wlansvc-id"])}
+	}
+
+	if !c.roleExists(attrs["role-id"]) {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute role-id: %s", attrs["role-id"])}
+	}
+
+	length, err := atoiDefault(attrs["dpsk-len"], 8)
+	if err != nil || length < 8 || length > 62 {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute dpsk-len: %s, must be between 8 and 62", attrs["dpsk-len"])}
+	}
+
+	if dvlan := attrs["dvlan-id"]; dvlan != "" {
+		if v, err := strconv.Atoi(dvlan); err != nil || v < 1 || v > 4094 {
+			return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute dvlan-id: %s", dvlan)}
+		}
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	for i := 1; i <= num; i++ {
+		entry := map[string]string{
+			"wlansvc-id":  attrs["wlansvc-id"],
+			"role-id":     attrs["role-id"],
+			"dvlan-id":    attrs["dvlan-id"],
+			"passphrase":  randomPassphrase(length),
+			"start-point": now,
+		}
+
+		if expire := attrs["expire"]; expire != "" {
+			entry["expire"] = expire
+		}
+
+		// The controller names batch generated users after the requested user with a numeric suffix
+		switch user := attrs["user"]; {
+		case user != "" && num == 1:
+			entry["user"] = user
+		case user != "":
+			entry["user"] = fmt.Sprintf("%s_%d", user, i)
+		}
+
+		c.addDpsk(entry)
+	}
+
+	return nil
+}
+
+func (c *Controller) updateDpsk(obj ajax.Element) error {
+	attrs := obj.AttrMap()
+
+	id, err := strconv.Atoi(attrs["id"])
+	if err != nil {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute id: %s", attrs["id"])}
+	}
+
+	entry, ok := c.dpsks[id]
+	if !ok {
+		return &Error{Code: "3", Msg: fmt.Sprintf("invalid attribute id: DPSK %d does not exist", id)}
+	}
+
+	known := make(map[string]bool, len(dpskAttrs))
+	for _, attr := range dpskAttrs {
+		known[attr] = true
+	}
+
+	// Validate everything before applying so a rejected update leaves the entry untouched
+	for k := range attrs {
+		switch {
+		case k == "id" || k == "name" || k == "IS_PARTIAL":
+		case !known[k]:
+			return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute: %s", k)}
+		case readOnlyDpskAttrs[k]:
+			return &Error{Code: "4", Msg: fmt.Sprintf("permission denied: attribute %s is read only", k)}
+		}
+	}
+
+	if wlan, ok := attrs["wlansvc-id"]; ok && !c.wlanExists(wlan) {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute wlansvc-id: %s", wlan)}
+	}
+
+	if role, ok := attrs["role-id"]; ok && !c.roleExists(role) {
+		return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute role-id: %s", role)}
+	}
+
+	for k, v := range attrs {
+		if k == "id" || k == "name" || k == "IS_PARTIAL" {
+			continue
+		}
+		entry[k] = v
+	}
+
+	return nil
+}
+
+// deleteDpsks removes every dpsk element of a delobj request, all ids must exist
+func (c *Controller) deleteDpsks(objs []ajax.Element) error {
+	ids := make([]int, 0, len(objs))
+	for _, obj := range objs {
+		value, _ := obj.Get("id")
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return &Error{Code: "2", Msg: fmt.Sprintf("invalid attribute id: %s", value)}
+		}
+		if _, ok := c.dpsks[id]; !ok {
+			return &Error{Code: "3", Msg: fmt.Sprintf("invalid attribute id: DPSK %d does not exist", id)}
+		}
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		delete(c.dpsks, id)
+	}
+
+	return nil
+}
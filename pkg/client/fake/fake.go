@@ -0,0 +1,479 @@
+// Package fake implements an in-process Ruckus controller emulating the subset of the
+// Unleashed/ZoneDirector web API used by pkg/client. It keeps all state in memory and is
+// meant for integration tests and demos, not for fidelity with every firmware quirk.
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+)
+
+const (
+	SessionCookie = "-ejs-session-"
+	CSRFHeader    = "HTTP_X_CSRF_TOKEN"
+	MaxDpsk       = 2048
+	Version       = "200.15.6.12.304"
+	Model         = "R650"
+)
+
+// loginPage is served for failed logins and, like the real controller, whenever an ajax
+// endpoint is hit without a valid session
+const loginPage = `<!DOCTYPE html>
+<html><head><title>Unleashed Login</title></head>
+<body><form method="post" action="/admin/login.jsp">
+<input name="username"><input type="password" name="password"><input type="submit" name="ok" value="Log In">
+</form></body></html>
+`
+
+type Wlan struct {
+	ID         int
+	Name       string
+	SSID       string
+	Encryption string
+	Dpsk       bool
+}
+
+type Role struct {
+	ID             int
+	Name           string
+	Description    string
+	AllowAllWlans  bool
+	AccessPolicies []string
+}
+
+// Station is a connected wireless client
+type Station struct {
+	Mac            string
+	IpAddr         string
+	Hostname       string
+	Ap             string
+	ApName         string
+	SSID           string
+	Rssi           int
+	User           string // DPSK user, reported with WlanID when set
+	WlanID         int
+	ConnectedSince time.Time
+}
+
+type session struct {
+	csrfToken string
+	created   time.Time
+}
+
+// Controller holds the emulated controller state and implements http.Handler
+type Controller struct {
+	Username   string
+	Password   string
+	SessionTTL time.Duration // Sessions older than this are rejected, zero keeps them forever
+
+	mu         sync.Mutex
+	sessions   map[string]session
+	wlans      []Wlan
+	roles      []Role
+	stations   []Station
+	dpsks      map[int]map[string]string
+	nextDpskID int
+	failures   []int // Status codes returned by the next requests, for testing retries
+	logins     int
+}
+
+// New returns a controller with a single DPSK enabled WLAN (id 1), the Default role (id 0)
+// and no DPSK entries
+func New(username, password string) *Controller {
+	return &Controller{
+		Username:   username,
+		Password:   password,
+		sessions:   make(map[string]session),
+		wlans:      []Wlan{{ID: 1, Name: "Staff", SSID: "Staff", Encryption: "wpa2", Dpsk: true}},
+		roles:      []Role{{ID: 0, Name: "Default", Description: "Default role", AllowAllWlans: true}},
+		dpsks:      make(map[int]map[string]string),
+		nextDpskID: 1,
+	}
+}
+
+// NewServer starts an httptest server backed by c. Callers must Close it.
+func NewServer(c *Controller) *httptest.Server {
+	return httptest.NewServer(c)
+}
+
+// AddWlan adds or replaces a WLAN service
+func (c *Controller) AddWlan(w Wlan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.wlans {
+		if c.wlans[i].ID == w.ID {
+			c.wlans[i] = w
+			return
+		}
+	}
+	c.wlans = append(c.wlans, w)
+}
+
+// AddRole adds or replaces a role
+func (c *Controller) AddRole(r Role) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.roles {
+		if c.roles[i].ID == r.ID {
+			c.roles[i] = r
+			return
+		}
+	}
+	c.roles = append(c.roles, r)
+}
+
+// AddStation adds or replaces a connected client, keyed by MAC address
+func (c *Controller) AddStation(st Station) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.stations {
+		if strings.EqualFold(c.stations[i].Mac, st.Mac) {
+			c.stations[i] = st
+			return
+		}
+	}
+	c.stations = append(c.stations, st)
+}
+
+// ExpireSessions invalidates every session, forcing clients to log in again
+func (c *Controller) ExpireSessions() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessions = make(map[string]session)
+}
+
+// InjectFailures makes the next requests fail with the given HTTP status codes, in order
+func (c *Controller) InjectFailures(statusCodes ...int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = append(c.failures, statusCodes...)
+}
+
+// Logins returns how many successful logins the controller has served
+func (c *Controller) Logins() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.logins
+}
+
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, ok := c.nextFailure(); ok {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/admin/login.jsp":
+		c.handleLogin(w, r)
+	case ajax.PathCmdStat, ajax.PathConf:
+		if !c.authorized(r, true) {
+			http.Redirect(w, r, "/admin/login.jsp", http.StatusFound)
+			return
+		}
+		c.handleAjax(w, r)
+	case "/admin/webPage/system/admin/_savebackup.jsp":
+		if !c.authorized(r, false) {
+			http.Redirect(w, r, "/admin/login.jsp", http.StatusFound)
+			return
+		}
+		c.handleBackup(w, r)
+	case "/admin/webPage/system/admin/_restore.jsp":
+		if !c.authorized(r, true) {
+			http.Redirect(w, r, "/admin/login.jsp", http.StatusFound)
+			return
+		}
+		c.handleRestore(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *Controller) nextFailure() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.failures) == 0 {
+		return 0, false
+	}
+	status := c.failures[0]
+	c.failures = c.failures[1:]
+	return status, true
+}
+
+func (c *Controller) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.FormValue("username") != c.Username || r.FormValue("password") != c.Password {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, loginPage)
+		return
+	}
+
+	id := randomHex(16)
+	token := randomHex(8)
+
+	c.mu.Lock()
+	c.sessions[id] = session{csrfToken: token, created: time.Now()}
+	c.logins++
+	c.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/", HttpOnly: true})
+	w.Header().Set(CSRFHeader, token)
+	http.Redirect(w, r, "/admin/dashboard.jsp", http.StatusFound)
+}
+
+// authorized validates the session cookie and, for ajax calls, the CSRF token
+func (c *Controller) authorized(r *http.Request, checkCSRF bool) bool {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sessions[cookie.Value]
+	if !ok {
+		return false
+	}
+
+	if c.SessionTTL > 0 && time.Since(s.created) > c.SessionTTL {
+		delete(c.sessions, cookie.Value)
+		return false
+	}
+
+	return !checkCSRF || r.Header.Get("X-CSRF-Token") == s.csrfToken
+}
+
+func (c *Controller) handleAjax(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := ajax.ParseRequest(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Action.Path() != r.URL.Path {
+		http.Error(w, fmt.Sprintf("action %s not served by %s", req.Action, r.URL.Path), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	children, err := c.dispatch(req)
+	c.mu.Unlock()
+
+	if err != nil {
+		children = []ajax.Element{errorElement(err)}
+	}
+
+	writeResponse(w, req.Updater, children...)
+}
+
+// dispatch runs an ajax request against the state, the caller must hold c.mu
+func (c *Controller) dispatch(req *ajax.Request) ([]ajax.Element, error) {
+	for _, el := range req.Elements {
+		switch {
+		case req.Action == ajax.ActionGetStat && el.Name == "dpsklist":
+			return []ajax.Element{c.dpskList()}, nil
+		case req.Action == ajax.ActionGetStat && el.Name == "client":
+			return []ajax.Element{c.stationList()}, nil
+		case req.Action == ajax.ActionGetStat && el.Name == "sysinfo":
+			return []ajax.Element{
+				ajax.NewElement("sysinfo", ajax.Attr("version", Version), ajax.Attr("model", Model)),
+				ajax.NewElement("identity", ajax.Attr("name", "Unleashed")),
+			}, nil
+		case req.Action == ajax.ActionDoCmd && el.Name == "xcmd":
+			return c.doCmd(el)
+		case req.Action == ajax.ActionUpdObj && el.Name == "dpsk":
+			return nil, c.updateDpsk(el)
+		case req.Action == ajax.ActionGetConf && el.Name == "wlansvc":
+			return []ajax.Element{c.wlanList()}, nil
+		case req.Action == ajax.ActionGetConf && el.Name == "role":
+			return []ajax.Element{c.roleList()}, nil
+		case req.Action == ajax.ActionDelObj && el.Name == "dpsk":
+			return nil, c.deleteDpsks(req.Elements)
+		}
+	}
+
+	return nil, &Error{Code: "1", Msg: fmt.Sprintf("unsupported %s request on %s", req.Action, req.Comp)}
+}
+
+func (c *Controller) wlanList() ajax.Element {
+	list := ajax.NewElement("wlansvc-list")
+	for _, w := range c.wlans {
+		dynamicPsk := "disabled"
+		if w.Dpsk {
+			dynamicPsk = "enabled"
+		}
+
+		entry := ajax.NewElement("wlansvc",
+			ajax.Attr("id", strconv.Itoa(w.ID)),
+			ajax.Attr("name", w.Name),
+			ajax.Attr("ssid", w.SSID),
+			ajax.Attr("encryption", w.Encryption),
+		)
+		entry.Children = append(entry.Children, ajax.NewElement("wpa",
+			ajax.Attr("cipher", "aes"),
+			ajax.Attr("dynamic-psk", dynamicPsk),
+		))
+		list.Children = append(list.Children, entry)
+	}
+	return list
+}
+
+func (c *Controller) roleList() ajax.Element {
+	list := ajax.NewElement("role-list")
+	for _, r := range c.roles {
+		entry := ajax.NewElement("role",
+			ajax.Attr("id", strconv.Itoa(r.ID)),
+			ajax.Attr("name", r.Name),
+			ajax.Attr("description", r.Description),
+			ajax.Attr("allow-all-wlansvc", strconv.FormatBool(r.AllowAllWlans)),
+		)
+		for i, policy := range r.AccessPolicies {
+			entry.Children = append(entry.Children, ajax.NewElement("acl",
+				ajax.Attr("id", strconv.Itoa(i+1)),
+				ajax.Attr("name", policy),
+			))
+		}
+		list.Children = append(list.Children, entry)
+	}
+	return list
+}
+
+func (c *Controller) stationList() ajax.Element {
+	stat := ajax.NewElement("apstamgr-stat")
+	for _, st := range c.stations {
+		client := ajax.NewElement("client",
+			ajax.Attr("mac", strings.ToLower(st.Mac)),
+			ajax.Attr("ip", st.IpAddr),
+			ajax.Attr("hostname", st.Hostname),
+			ajax.Attr("ap", st.Ap),
+			ajax.Attr("ap-name", st.ApName),
+			ajax.Attr("ssid", st.SSID),
+			ajax.Attr("rssi", strconv.Itoa(st.Rssi)),
+			ajax.Attr("first-assoc", strconv.FormatInt(st.ConnectedSince.Unix(), 10)),
+		)
+		if st.User != "" {
+			client.Set("user", st.User)
+			client.Set("wlan-id", strconv.Itoa(st.WlanID))
+		}
+		stat.Children = append(stat.Children, client)
+	}
+	return stat
+}
+
+func (c *Controller) doCmd(xcmd ajax.Element) ([]ajax.Element, error) {
+	cmd, _ := xcmd.Get("cmd")
+	switch cmd {
+	case "batch-dpsk":
+		if err := c.batchDpsk(xcmd.AttrMap()); err != nil {
+			return []ajax.Element{xmsg(err)}, nil
+		}
+		return []ajax.Element{xmsg(nil)}, nil
+	default:
+		return []ajax.Element{xmsg(fmt.Errorf("unknown command: %s", cmd))}, nil
+	}
+}
+
+// Error is reported inside the ajax-response body, the HTTP status stays 200 like on the real controller
+type Error struct {
+	Code string
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func errorElement(err error) ajax.Element {
+	code := "1"
+	if e, ok := err.(*Error); ok {
+		code = e.Code
+	}
+	return ajax.NewElement("error", ajax.Attr("code", code), ajax.Attr("msg", err.Error()))
+}
+
+// xmsg reports the result of a command, failures use the code of an *Error as status
+func xmsg(err error) ajax.Element {
+	if err != nil {
+		status := "1"
+		if e, ok := err.(*Error); ok {
+			status = e.Code
+		}
+		return ajax.NewElement("xmsg", ajax.Attr("status", status), ajax.Attr("lmsg", err.Error()))
+	}
+	return ajax.NewElement("xmsg", ajax.Attr("status", "0"))
+}
+
+func writeResponse(w http.ResponseWriter, id string, children ...ajax.Element) {
+	resp := ajax.Element{
+		Name: "ajax-response",
+		Children: []ajax.Element{{
+			Name:     "response",
+			Attrs:    []xml.Attr{ajax.Attr("type", "object"), ajax.Attr("id", id)},
+			Children: children,
+		}},
+	}
+
+	data, err := xml.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(data)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// passphraseChars mirrors the controller generator, which avoids easily confused characters
+const passphraseChars = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func randomPassphrase(length int) string {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(passphraseChars)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = passphraseChars[n.Int64()]
+	}
+	return string(b)
+}
+
+func atoiDefault(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Only idempotent requests
+// (reads, partial object updates and downloads) are ever retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first one, values below 2 disable retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled on every following attempt
+	MaxDelay    time.Duration // Upper bound for the delay between attempts
+	Jitter      float64       // Random fraction (0-1) added or removed from every delay
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Jitter:      0.2,
+}
+
+// Backoff returns the delay to wait after the given failed attempt (starting at 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// retryableStatus reports whether the controller answered with a transient failure
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+// send applies the rate limiter and retry policy to a request built by newRequest.
+// newRequest is called once per attempt so every attempt gets a fresh body and headers.
+func (rc *Client) send(ctx context.Context, idempotent bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := 1
+	if idempotent && rc.Retry.MaxAttempts > 1 {
+		attempts = rc.Retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		if rc.RateLimiter != nil {
+			if err := rc.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %v", err)
+		}
+
+		resp, err := rc.client.Do(req)
+
+		retryable := false
+		if err != nil {
+			// Never retry once the caller gave up
+			retryable = ctx.Err() == nil
+		} else {
+			retryable = retryableStatus(resp.StatusCode)
+		}
+
+		if !retryable || attempt >= attempts {
+			if err != nil {
+				return nil, fmt.Errorf("error sending request: %v", err)
+			}
+			return resp, nil
+		}
+
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		delay := rc.Retry.Backoff(attempt)
+		if rc.Debug {
+			if err != nil {
+				fmt.Printf("Request failed (%v), retrying in %v\n", err, delay)
+			} else {
+				fmt.Printf("Request failed with status code %d, retrying in %v\n", resp.StatusCode, delay)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
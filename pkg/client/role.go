@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/role"
+)
+
+type RoleService struct {
+	Client *Client
+}
+
+func (rc *Client) Role() *RoleService {
+	return &RoleService{Client: rc}
+}
+
+func (r *RoleService) List(ctx context.Context) (role.Entries, error) {
+	req := ajax.GetConf("role-list", ajax.NewElement("role"))
+
+	resp, err := r.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := role.FromXml(resp.Raw)
+	if r.Client.Debug {
+		fmt.Printf("Parsed roles:\n")
+		for _, role := range entries {
+			fmt.Printf("%v\n", role)
+		}
+	}
+
+	return entries, err
+}
+
+// ResolveID returns the role-id of the role with the given name
+func (r *RoleService) ResolveID(ctx context.Context, name string) (string, error) {
+	entries, err := r.List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error getting role list: %w", err)
+	}
+
+	entry, err := entries.FindByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.ID, nil
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session holds everything needed to reuse an authenticated controller session
+type Session struct {
+	Server    string    `json:"server"`
+	Username  string    `json:"username"`
+	CSRFToken string    `json:"csrf-token"`
+	Cookies   []Cookie  `json:"cookies"`
+	Created   time.Time `json:"created"`
+}
+
+// Cookie is the persisted subset of an http.Cookie
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type SessionStore interface {
+	Load(server, username string) (*Session, error) // Returns nil, nil when no session is stored
+	Save(session *Session) error
+	Delete(server, username string) error
+}
+
+// FileSessionStore persists one session file per server/username pair inside Dir
+type FileSessionStore struct {
+	Dir string
+}
+
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("error locating user cache directory: %v", err)
+		}
+		dir = filepath.Join(cacheDir, "ruckus-dpsk-manager", "sessions")
+	}
+
+	return &FileSessionStore{Dir: dir}, nil
+}
+
+func (s *FileSessionStore) path(server, username string) string {
+	sum := sha256.Sum256([]byte(server + "\x00" + username))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *FileSessionStore) Load(server, username string) (*Session, error) {
+	data, err := os.ReadFile(s.path(server, username))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session file: %v", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("error decoding session file: %v", err)
+	}
+
+	// Guard against hash collisions or hand edited files
+	if session.Server != server || session.Username != username {
+		return nil, nil
+	}
+
+	return &session, nil
+}
+
+func (s *FileSessionStore) Save(session *Session) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("error creating session directory: %v", err)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("error encoding session: %v", err)
+	}
+
+	// Write to a temporary file first so a concurrent reader never sees a partial session
+	tmp, err := os.CreateTemp(s.Dir, ".session-*")
+	if err != nil {
+		return fmt.Errorf("error creating session file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error setting session file permissions: %v", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing session file: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing session file: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(session.Server, session.Username)); err != nil {
+		return fmt.Errorf("error saving session file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *FileSessionStore) Delete(server, username string) error {
+	err := os.Remove(s.path(server, username))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting session file: %v", err)
+	}
+	return nil
+}
+
+func cookiesToSession(cookies []*http.Cookie) []Cookie {
+	result := make([]Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		result = append(result, Cookie{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
+
+func cookiesFromSession(cookies []Cookie) []*http.Cookie {
+	result := make([]*http.Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		result = append(result, &http.Cookie{Name: c.Name, Value: c.Value, Path: "/"})
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/station"
+)
+
+// ClientService queries the station manager for the wireless clients currently connected
+type ClientService struct {
+	Client *Client
+}
+
+func (rc *Client) Clients() *ClientService {
+	return &ClientService{Client: rc}
+}
+
+func (c *ClientService) List(ctx context.Context) (station.Entries, error) {
+	req := ajax.GetStat("stamgr", "client-list", ajax.NewElement("client", ajax.Attr("LEVEL", "1")))
+
+	resp, err := c.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := station.FromXml(resp.Raw)
+	if c.Client.Debug {
+		fmt.Printf("Parsed clients:\n")
+		for _, station := range entries {
+			fmt.Printf("%v\n", station)
+		}
+	}
+
+	return entries, err
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+)
+
+// SystemInfo identifies the controller hardware and firmware
+type SystemInfo struct {
+	Name    string `json:"name"`
+	Model   string `json:"model"`
+	Version string `json:"version"`
+}
+
+type systemResponse struct {
+	XMLName  xml.Name `xml:"ajax-response"`
+	Response struct {
+		Sysinfo struct {
+			Model   string `xml:"model,attr"`
+			Version string `xml:"version,attr"`
+		} `xml:"sysinfo"`
+		Identity struct {
+			Name string `xml:"name,attr"`
+		} `xml:"identity"`
+	} `xml:"response"`
+}
+
+func (rc *Client) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	req := ajax.GetStat("system", "system", ajax.NewElement("sysinfo"), ajax.NewElement("identity"))
+
+	resp, err := rc.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed systemResponse
+	if err := resp.Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	return &SystemInfo{
+		Name:    parsed.Response.Identity.Name,
+		Model:   parsed.Response.Sysinfo.Model,
+		Version: parsed.Response.Sysinfo.Version,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/client/ajax"
+	"github.com/miguelangel-nubla/ruckus-dpsk-manager/pkg/data/wlan"
+)
+
+type WlanService struct {
+	Client *Client
+}
+
+func (rc *Client) Wlan() *WlanService {
+	return &WlanService{Client: rc}
+}
+
+func (w *WlanService) List(ctx context.Context) (wlan.Entries, error) {
+	req := ajax.GetConf("wlansvc-list", ajax.NewElement("wlansvc"))
+
+	resp, err := w.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := wlan.FromXml(resp.Raw)
+	if w.Client.Debug {
+		fmt.Printf("Parsed WLANs:\n")
+		for _, wlan := range entries {
+			fmt.Printf("%v\n", wlan)
+		}
+	}
+
+	return entries, err
+}
+
+// ResolveID returns the wlansvc-id of the WLAN with the given SSID or name.
+// Numeric values are accepted as ids as long as the WLAN exists.
+func (w *WlanService) ResolveID(ctx context.Context, name string) (int, error) {
+	entries, err := w.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting WLAN list: %w", err)
+	}
+
+	entry, err := entries.FindByName(name)
+	if err != nil {
+		if id, convErr := strconv.Atoi(name); convErr == nil {
+			if _, ok := entries[id]; ok {
+				return id, nil
+			}
+		}
+		return 0, err
+	}
+
+	return entry.ID, nil
+}
@@ -0,0 +1,77 @@
+package dpsk
+
+import (
+	"reflect"
+	"time"
+)
+
+// Derived holds the attributes computed from the raw fields of a DPSK and the current time.
+// They can be used in filters like any other attribute but cannot be modified.
+type Derived struct {
+	Expired   bool     `json:"expired" _dpsk_attr:"expired"`       // Has an expiration date in the past
+	ExpiresIn Duration `json:"expires-in" _dpsk_attr:"expires-in"` // Time left until it expires, negative once expired, unset if it never expires
+	Bound     bool     `json:"bound" _dpsk_attr:"bound"`           // Bound to the MAC address of a device
+	Shared    bool     `json:"shared" _dpsk_attr:"shared"`         // Used by more than one device at the same time
+	Age       Duration `json:"age" _dpsk_attr:"age"`               // Time since its start point, unset if it did not start
+	Used      bool     `json:"used" _dpsk_attr:"used"`             // A device has ever connected with it
+}
+
+// Extended is a DPSK with its derived attributes, encoded as a single JSON object
+type Extended struct {
+	*Dpsk
+	Derived
+}
+
+// TableFields are the attributes shown by default when entries are listed as a table
+var TableFields = []string{"id", "user", "wlansvc-id", "role-id", "mac", "expire", "expires-in", "used"}
+
+var derivedTagMap map[string]string
+
+func init() {
+	derivedTagMap = createTagToFieldMap(Derived{}, "_dpsk_attr")
+}
+
+// IsDerived reports whether attr is a derived attribute
+func IsDerived(attr string) bool {
+	_, ok := derivedTagMap[attr]
+	return ok
+}
+
+// Derived computes the derived attributes as of now
+func (d *Dpsk) Derived(now time.Time) Derived {
+	derived := Derived{
+		Bound:  len(d.Mac) > 0,
+		Shared: d.CurSharedNum > 1,
+	}
+
+	if !d.Expire.IsNever() {
+		derived.Expired = !d.Expire.After(now)
+		derived.ExpiresIn = NewDuration(d.Expire.Sub(now))
+	}
+
+	if !d.StartPoint.IsNever() {
+		derived.Age = NewDuration(now.Sub(d.StartPoint.Time))
+	}
+
+	derived.Used = derived.Bound || d.CurSharedNum > 0 || d.Usage > 0
+
+	return derived
+}
+
+// Extend pairs the DPSK with its derived attributes as of now
+func (d *Dpsk) Extend(now time.Time) Extended {
+	return Extended{Dpsk: d, Derived: d.Derived(now)}
+}
+
+// Extend adds the derived attributes to every entry, computed for the same point in time
+func (list Entries) Extend(now time.Time) map[int]Extended {
+	result := make(map[int]Extended, len(list))
+	for id, entry := range list {
+		result[id] = entry.Extend(now)
+	}
+	return result
+}
+
+func (d Derived) value(attr string) interface{} {
+	return reflect.ValueOf(d).FieldByName(derivedTagMap[attr]).Interface()
+}
@@ -4,12 +4,70 @@ import (
 	"encoding/xml"
 	"fmt"
 	"reflect"
+	"strconv"
+	"time"
 )
 
 type Filter interface {
 	Test(string) bool
 }
 
+// ValueFilter is implemented by filters comparing typed attribute values, e.g. timestamps by
+// date or numbers by magnitude. Other filters receive the value formatted by FormatValue.
+type ValueFilter interface {
+	Filter
+	TestValue(value interface{}) bool
+}
+
+// EntryFilter is implemented by filters looking at whole entries, e.g. boolean expressions over
+// several attributes. They are stored in the filter map under a key that is not an attribute.
+type EntryFilter interface {
+	Filter
+	TestEntry(entry *Dpsk) bool
+}
+
+// AttributeFilter is implemented by filters stored under a key other than the attribute they test,
+// so several of them can apply to the same attribute, e.g. -glob-user and -not-user
+type AttributeFilter interface {
+	Filter
+	Attribute() string
+}
+
+// Match applies filter to an attribute value
+func Match(filter Filter, value interface{}) bool {
+	if vf, ok := filter.(ValueFilter); ok {
+		return vf.TestValue(value)
+	}
+	return filter.Test(FormatValue(value))
+}
+
+// FormatValue returns the string form of an attribute value used by filters, which is the form
+// stored by the controller: timestamps as Unix seconds (0 for never) and MAC addresses lowercase.
+// Durations are formatted as seconds, empty when unset.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case Timestamp:
+		return strconv.FormatInt(v.Unix(), 10)
+	case HardwareAddr:
+		return v.String()
+	case Duration:
+		if !v.Valid {
+			return ""
+		}
+		return strconv.FormatInt(int64(v.Duration/time.Second), 10)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 // Define the struct to match the XML structure
 type ajaxResponse struct {
 	XMLName  xml.Name `xml:"ajax-response"`
@@ -35,20 +93,20 @@ type tempEntries []*Dpsk
 type Entries map[int]*Dpsk
 
 type Dpsk struct {
-	ID           int    `xml:"id,attr" json:"id" _dpsk_attr:"id"`
-	RoleID       string `xml:"role-id,attr" json:"role-id" _dpsk_attr:"role-id"`
-	Mac          string `xml:"mac,attr" json:"mac" _dpsk_attr:"mac"`
-	WlansvcID    int    `xml:"wlansvc-id,attr" json:"wlansvc-id" _dpsk_attr:"wlansvc-id"`
-	DvlanID      int    `xml:"dvlan-id,attr" json:"dvlan-id" _dpsk_attr:"dvlan-id"`
-	User         string `xml:"user,attr" json:"user" _dpsk_attr:"user"`
-	LastRekey    string `xml:"last-rekey,attr" json:"last-rekey" _dpsk_attr:"last-rekey"`
-	NextRekey    string `xml:"next-rekey,attr" json:"next-rekey" _dpsk_attr:"next-rekey"`
-	Expire       string `xml:"expire,attr" json:"expire" _dpsk_attr:"expire"`
-	StartPoint   string `xml:"start-point,attr" json:"start-point" _dpsk_attr:"start-point"`
-	Passphrase   string `xml:"passphrase,attr" json:"passphrase" _dpsk_attr:"passphrase"`
-	IpAddr       string `xml:"ip-addr,attr" json:"ip-addr" _dpsk_attr:"ip-addr"`
-	CurSharedNum string `xml:"cur-shared-num,attr" json:"cur-shared-num" _dpsk_attr:"cur-shared-num"`
-	Usage        string `xml:"usage,attr" json:"usage" _dpsk_attr:"usage"`
+	ID           int          `xml:"id,attr" json:"id" _dpsk_attr:"id"`
+	RoleID       string       `xml:"role-id,attr" json:"role-id" _dpsk_attr:"role-id"`
+	Mac          HardwareAddr `xml:"mac,attr" json:"mac" _dpsk_attr:"mac"`
+	WlansvcID    int          `xml:"wlansvc-id,attr" json:"wlansvc-id" _dpsk_attr:"wlansvc-id"`
+	DvlanID      int          `xml:"dvlan-id,attr" json:"dvlan-id" _dpsk_attr:"dvlan-id"`
+	User         string       `xml:"user,attr" json:"user" _dpsk_attr:"user"`
+	LastRekey    Timestamp    `xml:"last-rekey,attr" json:"last-rekey" _dpsk_attr:"last-rekey"`
+	NextRekey    Timestamp    `xml:"next-rekey,attr" json:"next-rekey" _dpsk_attr:"next-rekey"`
+	Expire       Timestamp    `xml:"expire,attr" json:"expire" _dpsk_attr:"expire"`
+	StartPoint   Timestamp    `xml:"start-point,attr" json:"start-point" _dpsk_attr:"start-point"`
+	Passphrase   string       `xml:"passphrase,attr" json:"passphrase" _dpsk_attr:"passphrase"`
+	IpAddr       string       `xml:"ip-addr,attr" json:"ip-addr" _dpsk_attr:"ip-addr"`
+	CurSharedNum int          `xml:"cur-shared-num,attr" json:"cur-shared-num" _dpsk_attr:"cur-shared-num"`
+	Usage        int          `xml:"usage,attr" json:"usage" _dpsk_attr:"usage"`
 }
 
 var tagMap map[string]string
@@ -71,6 +129,24 @@ func createTagToFieldMap(i interface{}, tagKey string) map[string]string {
 	return result
 }
 
+// Value returns the value of the attribute with the given _dpsk_attr name, derived attributes
+// are computed as of now
+func (d *Dpsk) Value(attr string) (interface{}, error) {
+	return d.ValueAt(attr, time.Now())
+}
+
+func (d *Dpsk) ValueAt(attr string, now time.Time) (interface{}, error) {
+	if fieldName, ok := tagMap[attr]; ok {
+		return reflect.ValueOf(*d).FieldByName(fieldName).Interface(), nil
+	}
+
+	if IsDerived(attr) {
+		return d.Derived(now).value(attr), nil
+	}
+
+	return nil, fmt.Errorf("invalid tag: %s", attr)
+}
+
 func (list *Entries) FindByWlanUser(wlanID int, username string) (*Dpsk, error) {
 	for _, entry := range *list {
 		if entry.User == username && entry.WlansvcID == wlanID {
@@ -81,29 +157,35 @@ func (list *Entries) FindByWlanUser(wlanID int, username string) (*Dpsk, error)
 }
 
 func (list *Entries) Filter(filters map[string]Filter) (Entries, error) {
+	return list.FilterAt(filters, time.Now())
+}
+
+// FilterAt filters the entries computing derived attributes as of now
+func (list *Entries) FilterAt(filters map[string]Filter, now time.Time) (Entries, error) {
 	matches := make(Entries)
 
 	for _, dpsk := range *list {
 		match := true
 		for tag, filter := range filters {
-			fieldName, ok := tagMap[tag]
-			if !ok {
-				return nil, fmt.Errorf("invalid tag: %s", tag)
+			if ef, ok := filter.(EntryFilter); ok {
+				if !ef.TestEntry(dpsk) {
+					match = false
+					break
+				}
+				continue
 			}
 
-			field := reflect.ValueOf(*dpsk).FieldByName(fieldName)
+			attr := tag
+			if af, ok := filter.(AttributeFilter); ok {
+				attr = af.Attribute()
+			}
 
-			var value string
-			switch field.Kind() {
-			case reflect.String:
-				value = field.String()
-			case reflect.Int:
-				value = fmt.Sprintf("%d", field.Int())
-			default:
-				panic("invalid field type") // check Dpsk struct types
+			value, err := dpsk.ValueAt(attr, now)
+			if err != nil {
+				return nil, err
 			}
 
-			if filter.Test(value) == false {
+			if Match(filter, value) == false {
 				// end loop on first failed check
 				match = false
 				break
@@ -124,10 +206,23 @@ func FromXml(xmlData []byte) (Entries, error) {
 		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
 	}
 
+	return response.Response.Apstamgr.DpskList.toEntries(), nil
+}
+
+// FromListXml decodes a bare <dpsk-list> document, the layout used inside configuration backups
+func FromListXml(xmlData []byte) (Entries, error) {
+	var list dpskList
+	if err := xml.Unmarshal(xmlData, &list); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	return list.toEntries(), nil
+}
+
+func (list dpskList) toEntries() Entries {
 	dpskMap := make(Entries)
-	for _, dpsk := range response.Response.Apstamgr.DpskList.Entries {
+	for _, dpsk := range list.Entries {
 		dpskMap[dpsk.ID] = dpsk
 	}
-
-	return dpskMap, nil
+	return dpskMap
 }
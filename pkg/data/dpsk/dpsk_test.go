@@ -0,0 +1,25 @@
+package dpsk
+
+import "testing"
+
+func TestFromXmlMalformedAttributes(t *testing.T) {
+	data := []byte(`<ajax-response><response type="object" id="x"><apstamgr-stat><dpsk-list>
+<dpsk id="1" mac="not-a-mac" expire="soon" user="a" wlansvc-id="1"/>
+<dpsk id="2" mac="AA-BB-CC-DD-EE-FF" expire="1700000000" user="b" wlansvc-id="1" dvlan-id=""/>
+</dpsk-list></apstamgr-stat></response></ajax-response>`)
+
+	entries, err := FromXml(data)
+	if err != nil {
+		t.Fatalf("FromXml: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("FromXml returned %d entries, want 2", len(entries))
+	}
+
+	if e := entries[1]; e.Mac != nil || !e.Expire.IsNever() || e.User != "a" {
+		t.Errorf("malformed entry = %+v, want no MAC, never expiring", e)
+	}
+	if e := entries[2]; e.Mac.String() != "aa:bb:cc:dd:ee:ff" || e.Expire.Unix() != 1700000000 {
+		t.Errorf("valid entry = %+v", e)
+	}
+}
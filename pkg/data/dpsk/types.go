@@ -0,0 +1,300 @@
+package dpsk
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamp is a point in time stored by the controller as Unix seconds, where 0 means never.
+// The zero value is Never. It is encoded as RFC3339 or "never" in JSON.
+type Timestamp struct {
+	time.Time
+}
+
+// Never is the sentinel used for timestamps that are not set, e.g. keys that do not expire
+var Never = Timestamp{}
+
+const neverText = "never"
+
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Time: t.Truncate(time.Second)}
+}
+
+func (t Timestamp) IsNever() bool {
+	return t.Time.IsZero()
+}
+
+// Unix returns the controller representation, 0 for Never
+func (t Timestamp) Unix() int64 {
+	if t.IsNever() {
+		return 0
+	}
+	return t.Time.Unix()
+}
+
+func (t Timestamp) String() string {
+	if t.IsNever() {
+		return neverText
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// ParseTimestamp accepts "never", Unix seconds (0 meaning never) and RFC3339
+func ParseTimestamp(s string) (Timestamp, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, neverText) {
+		return Never, nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n == 0 {
+			return Never, nil
+		}
+		return Timestamp{Time: time.Unix(n, 0).UTC()}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return Never, fmt.Errorf("invalid timestamp %q, expected never, Unix seconds or RFC3339", s)
+	}
+	return NewTimestamp(t), nil
+}
+
+func (t Timestamp) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strconv.FormatInt(t.Unix(), 10)}, nil
+}
+
+// UnmarshalXMLAttr decodes the controller value leniently: a malformed value is read as Never
+// instead of failing the whole list
+func (t *Timestamp) UnmarshalXMLAttr(attr xml.Attr) error {
+	parsed, err := ParseTimestamp(attr.Value)
+	if err != nil {
+		parsed = Never
+	}
+	*t = parsed
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// Plain numbers are accepted as Unix seconds
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid timestamp %s", data)
+		}
+		s = strconv.FormatInt(n, 10)
+	}
+
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// HardwareAddr is the MAC address a DPSK is bound to, empty when it is not bound.
+// It is encoded in lowercase colon separated form.
+type HardwareAddr net.HardwareAddr
+
+func ParseHardwareAddr(s string) (HardwareAddr, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		return nil, err
+	}
+	return HardwareAddr(mac), nil
+}
+
+func (a HardwareAddr) String() string {
+	return net.HardwareAddr(a).String()
+}
+
+func (a HardwareAddr) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: a.String()}, nil
+}
+
+// UnmarshalXMLAttr decodes the controller value leniently: a malformed address is read as unbound
+// instead of failing the whole list
+func (a *HardwareAddr) UnmarshalXMLAttr(attr xml.Attr) error {
+	mac, err := ParseHardwareAddr(attr.Value)
+	if err != nil {
+		mac = nil
+	}
+	*a = mac
+	return nil
+}
+
+func (a HardwareAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+func (a *HardwareAddr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	mac, err := ParseHardwareAddr(s)
+	if err != nil {
+		return err
+	}
+	*a = mac
+	return nil
+}
+
+// Duration is a derived length of time that may be unset, e.g. the time left before a DPSK that
+// never expires expires. It is encoded in JSON as text like 6d23h59m0s, or null when unset.
+type Duration struct {
+	time.Duration
+	Valid bool
+}
+
+func NewDuration(d time.Duration) Duration {
+	return Duration{Duration: d.Truncate(time.Second), Valid: true}
+}
+
+// String formats the duration with a day unit, which time.Duration lacks
+func (d Duration) String() string {
+	if !d.Valid {
+		return ""
+	}
+	return FormatDuration(d.Duration)
+}
+
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	days := d / (24 * time.Hour)
+	rest := d % (24 * time.Hour)
+	if days == 0 {
+		return sign + rest.String()
+	}
+	if rest == 0 {
+		return fmt.Sprintf("%s%dd", sign, days)
+	}
+	return fmt.Sprintf("%s%dd%s", sign, days, rest.String())
+}
+
+// ParseDuration accepts Go durations extended with d (days) and w (weeks) units, e.g. 1w2d or 36h,
+// and plain numbers as seconds
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	invalid := fmt.Errorf("invalid duration %q, expected e.g. 7d, 1w2d, 36h or 90m", s)
+
+	rest := s
+	negative := false
+	if rest != "" && (rest[0] == '-' || rest[0] == '+') {
+		negative = rest[0] == '-'
+		rest = rest[1:]
+	}
+	if rest == "" {
+		return 0, invalid
+	}
+
+	var total time.Duration
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			break
+		}
+
+		var unit time.Duration
+		switch rest[i] {
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		}
+		if unit == 0 {
+			break
+		}
+
+		n, _ := strconv.ParseInt(rest[:i], 10, 64)
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+
+	if rest != "" {
+		// The sign applies to the whole duration, e.g. 1d-2h is rejected
+		if rest[0] == '-' || rest[0] == '+' {
+			return 0, invalid
+		}
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, invalid
+		}
+		total += d
+	}
+
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if !d.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Duration{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = NewDuration(parsed)
+	return nil
+}
+
+// SortKey orders timestamps chronologically, never after any time
+func (t Timestamp) SortKey() float64 {
+	if t.IsNever() {
+		return math.Inf(1)
+	}
+	return float64(t.Unix())
+}
+
+// SortKey orders durations by length, unset after any duration
+func (d Duration) SortKey() float64 {
+	if !d.Valid {
+		return math.Inf(1)
+	}
+	return d.Seconds()
+}
@@ -0,0 +1,59 @@
+package dpsk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		s    string
+		want time.Duration
+	}{
+		{"90", 90 * time.Second},
+		{"-30", -30 * time.Second},
+		{"7d", 7 * day},
+		{"1w2d", 9 * day},
+		{"2w", 14 * day},
+		{"36h", 36 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"1d12h", 36 * time.Hour},
+		{"1d2h3m4s", day + 2*time.Hour + 3*time.Minute + 4*time.Second},
+		{"-1w2d", -9 * day},
+		{"+3d", 3 * day},
+		{" 5d ", 5 * day},
+		{"0", 0},
+		{"0s", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.s)
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationErrors(t *testing.T) {
+	for _, s := range []string{"", "-", "+", "d", "1x", "1d2", "1d-2h", "--1d", "-+1d", "soon", "1.5d"} {
+		if got, err := ParseDuration(s); err == nil {
+			t.Errorf("ParseDuration(%q) = %v, want an error", s, got)
+		}
+	}
+}
+
+func TestFormatDurationRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{0, 45 * time.Second, 36 * time.Hour, -50 * time.Hour, 9*24*time.Hour + 90*time.Minute} {
+		s := FormatDuration(d)
+		got, err := ParseDuration(s)
+		if err != nil || got != d {
+			t.Errorf("ParseDuration(FormatDuration(%v) = %q) = %v, %v", d, s, got, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package role
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Define the struct to match the XML structure
+type ajaxResponse struct {
+	XMLName  xml.Name `xml:"ajax-response"`
+	Response response `xml:"response"`
+}
+
+type response struct {
+	Type     string   `xml:"type,attr"`
+	ID       string   `xml:"id,attr"`
+	RoleList roleList `xml:"role-list"`
+}
+
+type roleList struct {
+	Entries []*Role `xml:"role"`
+}
+
+type wlanRef struct {
+	ID int `xml:"id,attr"`
+}
+
+type policyRef struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type Entries map[string]*Role
+
+type Role struct {
+	ID             string      `xml:"id,attr" json:"id"`
+	Name           string      `xml:"name,attr" json:"name"`
+	Description    string      `xml:"description,attr" json:"description"`
+	AllowAllWlans  bool        `xml:"allow-all-wlansvc,attr" json:"allow-all-wlansvc"`
+	Wlans          []int       `xml:"-" json:"wlansvc-ids"`
+	AccessPolicies []string    `xml:"-" json:"access-policies"`
+	WlanRefs       []wlanRef   `xml:"wlansvc" json:"-"`
+	PolicyRefs     []policyRef `xml:"acl" json:"-"`
+}
+
+// FindByName returns the role with the given name, compared case insensitively
+func (list *Entries) FindByName(name string) (*Role, error) {
+	var matches []*Role
+	for _, entry := range *list {
+		if strings.EqualFold(entry.Name, name) {
+			matches = append(matches, entry)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown role: %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, m := range matches {
+			ids = append(ids, m.ID)
+		}
+		sort.Strings(ids)
+		return nil, fmt.Errorf("ambiguous role %s matches ids: %s", name, strings.Join(ids, ", "))
+	}
+}
+
+func FromXml(xmlData []byte) (Entries, error) {
+	var response ajaxResponse
+	if err := xml.Unmarshal(xmlData, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	return response.Response.RoleList.toEntries(), nil
+}
+
+// FromListXml decodes a bare <role-list> document, the layout used inside configuration backups
+func FromListXml(xmlData []byte) (Entries, error) {
+	var list roleList
+	if err := xml.Unmarshal(xmlData, &list); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	return list.toEntries(), nil
+}
+
+func (list roleList) toEntries() Entries {
+	roleMap := make(Entries)
+	for _, role := range list.Entries {
+		role.Wlans = make([]int, 0, len(role.WlanRefs))
+		for _, ref := range role.WlanRefs {
+			role.Wlans = append(role.Wlans, ref.ID)
+		}
+
+		role.AccessPolicies = make([]string, 0, len(role.PolicyRefs))
+		for _, ref := range role.PolicyRefs {
+			name := ref.Name
+			if name == "" {
+				name = ref.ID
+			}
+			role.AccessPolicies = append(role.AccessPolicies, name)
+		}
+
+		roleMap[role.ID] = role
+	}
+
+	return roleMap
+}
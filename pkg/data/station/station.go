@@ -0,0 +1,111 @@
+package station
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Define the struct to match the XML structure
+type ajaxResponse struct {
+	XMLName  xml.Name `xml:"ajax-response"`
+	Response response `xml:"response"`
+}
+
+type response struct {
+	Type     string       `xml:"type,attr"`
+	ID       string       `xml:"id,attr"`
+	Apstamgr apstamgrStat `xml:"apstamgr-stat"`
+}
+
+type apstamgrStat struct {
+	Entries []*Station `xml:"client"`
+}
+
+// Entries maps lowercase MAC addresses to the active client sessions
+type Entries map[string]*Station
+
+type Station struct {
+	Mac            string    `xml:"mac,attr" json:"mac"`
+	IpAddr         string    `xml:"ip,attr" json:"ip-addr"`
+	Hostname       string    `xml:"hostname,attr" json:"hostname"`
+	Ap             string    `xml:"ap,attr" json:"ap"`
+	ApName         string    `xml:"ap-name,attr" json:"ap-name"`
+	SSID           string    `xml:"ssid,attr" json:"ssid"`
+	Rssi           Int       `xml:"rssi,attr" json:"rssi"`
+	User           string    `xml:"user,attr" json:"user"`                    // DPSK user the client authenticated with
+	DpskID         Int       `xml:"dpsk-id,attr" json:"dpsk-id,omitempty"`    // Reported by firmwares that identify the DPSK
+	WlanID         Int       `xml:"wlan-id,attr" json:"wlansvc-id,omitempty"` // WLAN service of the session
+	FirstAssoc     Int       `xml:"first-assoc,attr" json:"-"`
+	ConnectedSince time.Time `xml:"-" json:"connected-since"`
+}
+
+// Int is a numeric attribute decoded leniently, firmwares send empty or non-numeric values such as
+// rssi="" for some clients. Those are read as 0 instead of failing the whole list.
+type Int int64
+
+func (n *Int) UnmarshalXMLAttr(attr xml.Attr) error {
+	v, err := strconv.ParseInt(strings.TrimSpace(attr.Value), 10, 64)
+	if err != nil {
+		v = 0
+	}
+	*n = Int(v)
+	return nil
+}
+
+// FindByMac returns the active session of a MAC address, compared case insensitively
+func (list *Entries) FindByMac(mac string) (*Station, bool) {
+	if mac == "" {
+		return nil, false
+	}
+	entry, ok := (*list)[strings.ToLower(mac)]
+	return entry, ok
+}
+
+// ForDpsk returns the active sessions using a DPSK, sorted by MAC address. Sessions are matched by
+// DPSK id when the controller reports it, otherwise by user and WLAN, and only then by the MAC
+// address the DPSK is bound to, which finds the first device of a shared DPSK only.
+func (list *Entries) ForDpsk(id int, user string, wlanID int, mac string) []*Station {
+	var sessions []*Station
+	for _, st := range *list {
+		var match bool
+		switch {
+		case st.DpskID > 0:
+			match = st.DpskID == Int(id)
+		case st.User != "":
+			match = st.User == user && (st.WlanID == 0 || st.WlanID == Int(wlanID))
+		default:
+			match = mac != "" && strings.EqualFold(st.Mac, mac)
+		}
+
+		if match {
+			sessions = append(sessions, st)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return strings.ToLower(sessions[i].Mac) < strings.ToLower(sessions[j].Mac)
+	})
+
+	return sessions
+}
+
+func FromXml(xmlData []byte) (Entries, error) {
+	var response ajaxResponse
+	if err := xml.Unmarshal(xmlData, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	stationMap := make(Entries)
+	for _, station := range response.Response.Apstamgr.Entries {
+		if station.FirstAssoc > 0 {
+			station.ConnectedSince = time.Unix(int64(station.FirstAssoc), 0)
+		}
+		stationMap[strings.ToLower(station.Mac)] = station
+	}
+
+	return stationMap, nil
+}
@@ -0,0 +1,59 @@
+package station
+
+import "testing"
+
+func TestFromXmlLenientNumbers(t *testing.T) {
+	data := []byte(`<ajax-response><response type="object" id="x"><apstamgr-stat>
+<client mac="AA:BB:CC:00:00:01" rssi="" dpsk-id="" wlan-id="n/a" first-assoc="1700000000" user="alice"/>
+<client mac="aa:bb:cc:00:00:02" rssi="-61" dpsk-id="7" wlan-id="3" first-assoc="bogus"/>
+<client mac="aa:bb:cc:00:00:03" user="bob" wlan-id="3"/>
+<client mac="aa:bb:cc:00:00:04"/>
+</apstamgr-stat></response></ajax-response>`)
+
+	entries, err := FromXml(data)
+	if err != nil {
+		t.Fatalf("FromXml: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("FromXml returned %d clients, want 4", len(entries))
+	}
+
+	first, _ := entries.FindByMac("aa:bb:cc:00:00:01")
+	if first.Rssi != 0 || first.DpskID != 0 || first.WlanID != 0 || first.ConnectedSince.Unix() != 1700000000 {
+		t.Errorf("client 1 = %+v", first)
+	}
+	second, _ := entries.FindByMac("AA:BB:CC:00:00:02")
+	if second.Rssi != -61 || second.DpskID != 7 || second.WlanID != 3 || !second.ConnectedSince.IsZero() {
+		t.Errorf("client 2 = %+v", second)
+	}
+
+	tests := []struct {
+		id     int
+		user   string
+		wlanID int
+		mac    string
+		want   []string
+	}{
+		{7, "", 3, "", []string{"aa:bb:cc:00:00:02"}},
+		{8, "alice", 1, "", []string{"AA:BB:CC:00:00:01"}},
+		{9, "bob", 3, "aa:bb:cc:00:00:04", []string{"aa:bb:cc:00:00:03", "aa:bb:cc:00:00:04"}},
+		{9, "bob", 4, "", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, st := range entries.ForDpsk(tt.id, tt.user, tt.wlanID, tt.mac) {
+			got = append(got, st.Mac)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ForDpsk(%d, %s, %d, %s) = %v, want %v", tt.id, tt.user, tt.wlanID, tt.mac, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ForDpsk(%d, %s, %d, %s) = %v, want %v", tt.id, tt.user, tt.wlanID, tt.mac, got, tt.want)
+				break
+			}
+		}
+	}
+}
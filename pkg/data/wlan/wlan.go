@@ -0,0 +1,92 @@
+package wlan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Define the struct to match the XML structure
+type ajaxResponse struct {
+	XMLName  xml.Name `xml:"ajax-response"`
+	Response response `xml:"response"`
+}
+
+type response struct {
+	Type     string   `xml:"type,attr"`
+	ID       string   `xml:"id,attr"`
+	WlanList wlanList `xml:"wlansvc-list"`
+}
+
+type wlanList struct {
+	Entries []*Wlan `xml:"wlansvc"`
+}
+
+type wpa struct {
+	Cipher     string `xml:"cipher,attr"`
+	DynamicPsk string `xml:"dynamic-psk,attr"`
+}
+
+type Entries map[int]*Wlan
+
+type Wlan struct {
+	ID         int    `xml:"id,attr" json:"id"`
+	Name       string `xml:"name,attr" json:"name"`
+	SSID       string `xml:"ssid,attr" json:"ssid"`
+	Encryption string `xml:"encryption,attr" json:"encryption"`
+	Dpsk       bool   `xml:"-" json:"dpsk"`
+	Wpa        *wpa   `xml:"wpa" json:"-"`
+}
+
+// FindByName returns the WLAN whose SSID or name matches exactly
+func (list *Entries) FindByName(name string) (*Wlan, error) {
+	var matches []*Wlan
+	for _, entry := range *list {
+		if entry.SSID == name || entry.Name == name {
+			matches = append(matches, entry)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown WLAN: %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, m := range matches {
+			ids = append(ids, fmt.Sprintf("%d", m.ID))
+		}
+		sort.Strings(ids)
+		return nil, fmt.Errorf("ambiguous WLAN %s matches ids: %s", name, strings.Join(ids, ", "))
+	}
+}
+
+func FromXml(xmlData []byte) (Entries, error) {
+	var response ajaxResponse
+	if err := xml.Unmarshal(xmlData, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	return response.Response.WlanList.toEntries(), nil
+}
+
+// FromListXml decodes a bare <wlansvc-list> document, the layout used inside configuration backups
+func FromListXml(xmlData []byte) (Entries, error) {
+	var list wlanList
+	if err := xml.Unmarshal(xmlData, &list); err != nil {
+		return nil, fmt.Errorf("error unmarshalling XML: %v", err)
+	}
+
+	return list.toEntries(), nil
+}
+
+func (list wlanList) toEntries() Entries {
+	wlanMap := make(Entries)
+	for _, wlan := range list.Entries {
+		wlan.Dpsk = wlan.Wpa != nil && wlan.Wpa.DynamicPsk == "enabled"
+		wlanMap[wlan.ID] = wlan
+	}
+	return wlanMap
+}